@@ -0,0 +1,380 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// OverflowPolicy decides what happens to an entry logged while the async buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the buffer
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the entry being logged
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest buffered entry to make room
+	OverflowDropOldest
+	// OverflowDropBelowLevel drops the entry being logged when it is below the drop level
+	// and waits for room otherwise, see WithAsyncDropLevel
+	OverflowDropBelowLevel
+)
+
+const (
+	defaultAsyncBufferSize    = 1024
+	defaultAsyncFlushInterval = time.Second
+	// asyncBatchSize is how many bytes are gathered before they are written
+	asyncBatchSize = 256 * 1024
+)
+
+var errAsyncClosed = errors.New("async writer is closed")
+
+type asyncConfig struct {
+	bufferSize    int
+	policy        OverflowPolicy
+	dropLevel     LogLevel
+	flushInterval time.Duration
+}
+
+// AsyncOption configures the buffer of WithAsync
+type AsyncOption interface {
+	applyAsyncOption(*asyncConfig) error
+}
+
+type applyAsyncOptionFunc func(*asyncConfig) error
+
+func (f applyAsyncOptionFunc) applyAsyncOption(c *asyncConfig) error {
+	return f(c)
+}
+
+// WithAsyncBufferSize sets how many entries are buffered; 1024 by default
+func WithAsyncBufferSize(size int) AsyncOption {
+	return applyAsyncOptionFunc(func(c *asyncConfig) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid async buffer size: %d", size)
+		}
+		c.bufferSize = size
+		return nil
+	})
+}
+
+// WithAsyncOverflowPolicy sets what happens when the buffer is full; OverflowBlock by default
+func WithAsyncOverflowPolicy(policy OverflowPolicy) AsyncOption {
+	return applyAsyncOptionFunc(func(c *asyncConfig) error {
+		if policy < OverflowBlock || policy > OverflowDropBelowLevel {
+			return fmt.Errorf("invalid async overflow policy: %d", policy)
+		}
+		c.policy = policy
+		return nil
+	})
+}
+
+// WithAsyncDropLevel sets the level under which entries are dropped by OverflowDropBelowLevel;
+// WarnLevel by default so debug and info entries are dropped
+func WithAsyncDropLevel(level LogLevel) AsyncOption {
+	return applyAsyncOptionFunc(func(c *asyncConfig) error {
+		c.dropLevel = level
+		return nil
+	})
+}
+
+// WithAsyncFlushInterval sets how often the buffered entries are written; every second by default
+func WithAsyncFlushInterval(interval time.Duration) AsyncOption {
+	return applyAsyncOptionFunc(func(c *asyncConfig) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid async flush interval: %s", interval)
+		}
+		c.flushInterval = interval
+		return nil
+	})
+}
+
+type asyncEntry struct {
+	level LogLevel
+	b     []byte
+}
+
+// asyncSink writes the entries queued by the logging goroutines from a single goroutine
+type asyncSink struct {
+	dropped uint64
+
+	config asyncConfig
+	out    zapcore.WriteSyncer
+
+	queue  chan asyncEntry
+	syncs  chan chan error
+	stop   chan struct{}
+	done   chan struct{}
+	closed sync.Once
+
+	// err is the first write error since the last sync
+	errMu sync.Mutex
+	err   error
+}
+
+func newAsyncSink(config asyncConfig, out zapcore.WriteSyncer) *asyncSink {
+	s := &asyncSink{
+		config: config,
+		out:    out,
+		queue:  make(chan asyncEntry, config.bufferSize),
+		syncs:  make(chan chan error),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// enqueue buffers the entry following the overflow policy
+func (s *asyncSink) enqueue(e asyncEntry) {
+	// checked on its own as select picks randomly between ready cases
+	select {
+	case <-s.stop:
+		atomic.AddUint64(&s.dropped, 1)
+		return
+	default:
+	}
+
+	select {
+	case s.queue <- e:
+		return
+	default:
+	}
+
+	policy := s.config.policy
+	if policy == OverflowDropBelowLevel {
+		if e.level < s.config.dropLevel {
+			policy = OverflowDropNewest
+		} else {
+			policy = OverflowBlock
+		}
+	}
+
+	switch policy {
+	case OverflowDropNewest:
+		atomic.AddUint64(&s.dropped, 1)
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-s.stop:
+				atomic.AddUint64(&s.dropped, 1)
+				return
+			case s.queue <- e:
+				return
+			default:
+			}
+			select {
+			case <-s.queue:
+				atomic.AddUint64(&s.dropped, 1)
+			default:
+			}
+		}
+	default:
+		select {
+		case <-s.stop:
+			atomic.AddUint64(&s.dropped, 1)
+		case s.queue <- e:
+		}
+	}
+}
+
+func (s *asyncSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]byte, 0, asyncBatchSize)
+	add := func(e asyncEntry) {
+		batch = append(batch, e.b...)
+		if len(batch) >= asyncBatchSize {
+			batch = s.write(batch)
+		}
+	}
+	// drain adds the entries that are already queued
+	drain := func() {
+		for {
+			select {
+			case e := <-s.queue:
+				add(e)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case e := <-s.queue:
+			add(e)
+		case <-ticker.C:
+			batch = s.write(batch)
+		case res := <-s.syncs:
+			drain()
+			batch = s.write(batch)
+			res <- s.sync()
+		case <-s.stop:
+			drain()
+			batch = s.write(batch)
+			s.setErr(s.out.Sync())
+			return
+		}
+	}
+}
+
+// write writes the batch and returns it emptied
+func (s *asyncSink) write(batch []byte) []byte {
+	if len(batch) == 0 {
+		return batch
+	}
+	if _, err := s.out.Write(batch); err != nil {
+		s.setErr(err)
+	}
+	return batch[:0]
+}
+
+func (s *asyncSink) setErr(err error) {
+	if err == nil {
+		return
+	}
+	s.errMu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.errMu.Unlock()
+}
+
+// sync syncs the output and returns the first error since the last sync
+func (s *asyncSink) sync() error {
+	s.setErr(s.out.Sync())
+
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// Sync writes the entries queued before the call and syncs the output
+func (s *asyncSink) Sync() error {
+	res := make(chan error, 1)
+	select {
+	case s.syncs <- res:
+		return <-res
+	case <-s.done:
+		return errAsyncClosed
+	}
+}
+
+// Close writes the queued entries and stops the writing goroutine.
+// Entries logged after Close are dropped.
+func (s *asyncSink) Close() error {
+	s.closed.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// Dropped returns how many entries were dropped
+func (s *asyncSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// asyncCore encodes the entries on the logging goroutine and hands them to the sink
+type asyncCore struct {
+	zapcore.LevelEnabler
+	enc  zapcore.Encoder
+	sink *asyncSink
+}
+
+func newAsyncCore(enc zapcore.Encoder, sink *asyncSink, enab zapcore.LevelEnabler) zapcore.Core {
+	return &asyncCore{
+		LevelEnabler: enab,
+		enc:          enc,
+		sink:         sink,
+	}
+}
+
+func (c *asyncCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &asyncCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          c.enc.Clone(),
+		sink:         c.sink,
+	}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *asyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *asyncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	// the buffer goes back to the pool so the bytes are copied
+	b := make([]byte, buf.Len())
+	copy(b, buf.Bytes())
+	buf.Free()
+
+	c.sink.enqueue(asyncEntry{level: ent.Level, b: b})
+
+	if ent.Level > ErrorLevel {
+		// the process may be about to panic or exit
+		return c.sink.Sync()
+	}
+	return nil
+}
+
+func (c *asyncCore) Sync() error {
+	return c.sink.Sync()
+}
+
+// asyncOutputs returns the outputs written by the async sink: the log files, the writers
+// and stdout or stderr unless writers replace stderr like they do without WithAsync
+func asyncOutputs(config *Config) (zapcore.WriteSyncer, []io.WriteCloser, error) {
+	syncers := []zapcore.WriteSyncer{}
+	files := []io.WriteCloser{}
+	for _, output := range config.zap.OutputPaths {
+		switch output {
+		case "stderr":
+			if len(config.writers) == 0 {
+				syncers = append(syncers, os.Stderr)
+			}
+		case "stdout":
+			syncers = append(syncers, os.Stdout)
+		default:
+			f, err := newRotatingFile(output, config.rotation)
+			if err != nil {
+				closeFiles(files)
+				return nil, nil, err
+			}
+			files = append(files, f)
+			syncers = append(syncers, f)
+		}
+	}
+	for _, w := range config.writers {
+		syncers = append(syncers, zapcore.AddSync(w))
+	}
+	return zapcore.NewMultiWriteSyncer(syncers...), files, nil
+}
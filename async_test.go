@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// blockingWriter blocks the writes until release is closed
+type blockingWriter struct {
+	syncBuffer
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+	return w.syncBuffer.Write(p)
+}
+
+func messages(lines []map[string]interface{}) []string {
+	out := []string{}
+	for _, line := range lines {
+		out = append(out, line["msg"].(string))
+	}
+	return out
+}
+
+func TestWithAsync_overflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []AsyncOption
+		log         func(l *logger, wg *sync.WaitGroup)
+		want        []string
+		wantDropped uint64
+	}{
+		{
+			name: "should pass; block",
+			log: func(l *logger, wg *sync.WaitGroup) {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					l.Info("3")
+				}()
+			},
+			want: []string{"0", "1", "2", "3"},
+		},
+		{
+			name: "should pass; drop newest",
+			opts: []AsyncOption{WithAsyncOverflowPolicy(OverflowDropNewest)},
+			log: func(l *logger, wg *sync.WaitGroup) {
+				l.Info("3")
+				l.Error("4")
+			},
+			want:        []string{"0", "1", "2"},
+			wantDropped: 2,
+		},
+		{
+			name: "should pass; drop oldest",
+			opts: []AsyncOption{WithAsyncOverflowPolicy(OverflowDropOldest)},
+			log: func(l *logger, wg *sync.WaitGroup) {
+				l.Info("3")
+				l.Info("4")
+			},
+			want:        []string{"0", "3", "4"},
+			wantDropped: 2,
+		},
+		{
+			name: "should pass; drop below level",
+			opts: []AsyncOption{WithAsyncOverflowPolicy(OverflowDropBelowLevel), WithAsyncDropLevel(WarnLevel)},
+			log: func(l *logger, wg *sync.WaitGroup) {
+				l.Info("3")
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					l.Warn("4")
+				}()
+			},
+			want:        []string{"0", "1", "2", "4"},
+			wantDropped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newBlockingWriter()
+			opts := append([]AsyncOption{WithAsyncBufferSize(2), WithAsyncFlushInterval(time.Hour)}, tt.opts...)
+			logr, err := New(WithEncoding(jsonEncoder), WithWriters(w), WithAsync(opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer logr.Close()
+
+			// the first entry blocks the writing goroutine so the next ones fill the buffer
+			logr.Info("0")
+			go logr.Sync()
+			<-w.started
+			logr.Info("1")
+			logr.Info("2")
+
+			wg := &sync.WaitGroup{}
+			tt.log(logr, wg)
+			// let the blocked goroutines reach the full buffer
+			time.Sleep(20 * time.Millisecond)
+
+			close(w.release)
+			wg.Wait()
+			if err := logr.Sync(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := messages(w.lines(t, len(tt.want)))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+			if got := logr.DroppedEntries(); got != tt.wantDropped {
+				t.Errorf("DroppedEntries() = %v, want %v", got, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestWithAsync_flush(t *testing.T) {
+	t.Run("should pass; on sync", func(t *testing.T) {
+		buf := &syncBuffer{}
+		logr, err := New(WithEncoding(jsonEncoder), WithWriters(buf), WithAsync(WithAsyncFlushInterval(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer logr.Close()
+
+		logr.Info("first")
+		logr.WithField("key", "value").Info("second")
+		if got := buf.String(); got != "" {
+			t.Fatalf("entries written before the flush: %s", got)
+		}
+		if err := logr.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		lines := buf.lines(t, 2)
+		if diff := cmp.Diff([]string{"first", "second"}, messages(lines)); diff != "" {
+			t.Errorf("messages mismatch (-want +got):\n%s", diff)
+		}
+		if lines[1]["key"] != "value" {
+			t.Errorf("key = %v, want value", lines[1]["key"])
+		}
+	})
+
+	t.Run("should pass; on the interval", func(t *testing.T) {
+		buf := &syncBuffer{}
+		logr, err := New(WithEncoding(jsonEncoder), WithWriters(buf), WithAsync(WithAsyncFlushInterval(10*time.Millisecond)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer logr.Close()
+
+		logr.Info("ticked")
+		if diff := cmp.Diff([]string{"ticked"}, messages(buf.lines(t, 1))); diff != "" {
+			t.Errorf("messages mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("should pass; on close", func(t *testing.T) {
+		buf := &syncBuffer{}
+		logr, err := New(WithEncoding(jsonEncoder), WithWriters(buf), WithAsync(WithAsyncFlushInterval(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		logr.Info("closing")
+		if err := logr.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"closing"}, messages(buf.lines(t, 1))); diff != "" {
+			t.Errorf("messages mismatch (-want +got):\n%s", diff)
+		}
+
+		logr.Info("after close")
+		if got := logr.DroppedEntries(); got != 1 {
+			t.Errorf("DroppedEntries() = %v, want 1", got)
+		}
+	})
+}
+
+func TestWithAsync_options(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []AsyncOption
+		wantErr bool
+	}{
+		{name: "should pass; defaults"},
+		{name: "should fail; buffer size", opts: []AsyncOption{WithAsyncBufferSize(0)}, wantErr: true},
+		{name: "should fail; policy", opts: []AsyncOption{WithAsyncOverflowPolicy(OverflowPolicy(9))}, wantErr: true},
+		{name: "should fail; flush interval", opts: []AsyncOption{WithAsyncFlushInterval(0)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logr, err := New(WithWriters(&syncBuffer{}), WithAsync(tt.opts...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if logr != nil {
+				logr.Close()
+			}
+		})
+	}
+}
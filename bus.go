@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"github.com/joematpal/go-logger/event"
+	"go.uber.org/zap/zapcore"
+)
+
+// eventCore publishes the encoded entries on an event bus
+type eventCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	bus event.TopicPublisher
+}
+
+func newEventCore(config *Config, bus event.TopicPublisher) (newCoreFunc, error) {
+	enc, err := newEncoder(config.zap.Encoding, config.zap.EncoderConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c zapcore.Core) zapcore.Core {
+		ec := &eventCore{
+			LevelEnabler: config.zap.Level,
+			enc:          enc,
+			bus:          bus,
+		}
+		return zapcore.NewTee(c, ec.With(initialFields(config)))
+	}, nil
+}
+
+func (c *eventCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &eventCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          c.enc.Clone(),
+		bus:          c.bus,
+	}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *eventCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *eventCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	// the subscribers keep the bytes after the buffer goes back to the pool
+	b := make([]byte, buf.Len())
+	copy(b, buf.Bytes())
+	buf.Free()
+
+	c.bus.PublishTopic(entryTopic(ent), b)
+	return nil
+}
+
+func (c *eventCore) Sync() error {
+	return nil
+}
+
+// entryTopic is the level of the entry followed by the name of the logger, like "error.api.http"
+func entryTopic(ent zapcore.Entry) string {
+	if ent.LoggerName == "" {
+		return ent.Level.String()
+	}
+	return ent.Level.String() + "." + ent.LoggerName
+}
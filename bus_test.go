@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/joematpal/go-logger/event"
+)
+
+func TestWithEventBus(t *testing.T) {
+	bus := event.New()
+	logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(&syncBuffer{}), WithEventBus(bus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logr.Close()
+
+	errs := bus.SubscribeTopic("error.>")
+	api := bus.SubscribeTopic("*.api.>")
+
+	logr.Info("root info")
+	logr.Error("root error")
+	apiLogr := logr.Named("api")
+	apiLogr.Info("api info")
+	apiLogr.WithField("key", "value").Error("api error")
+	logr.Named("db").Error("db error")
+	bus.Close()
+
+	tests := []struct {
+		name string
+		itr  event.FactoryEvent
+		want []string
+	}{
+		{
+			name: "should pass; errors of every logger",
+			itr:  errs,
+			want: []string{"root error", "api error", "db error"},
+		},
+		{
+			name: "should pass; everything logged by api",
+			itr:  api,
+			want: []string{"api info", "api error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			timeout := time.After(5 * time.Second)
+			for {
+				select {
+				case data, ok := <-tt.itr.Data():
+					if !ok {
+						if diff := cmp.Diff(tt.want, got); diff != "" {
+							t.Errorf("messages mismatch (-want +got):\n%s", diff)
+						}
+						return
+					}
+					var msg map[string]interface{}
+					if err := json.Unmarshal(data, &msg); err != nil {
+						t.Fatalf("unmarshal %q: %v", data, err)
+					}
+					got = append(got, msg["msg"].(string))
+				case <-timeout:
+					t.Fatalf("timed out with %v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithEventBus_nil(t *testing.T) {
+	if _, err := New(WithEventBus(nil)); err == nil {
+		t.Error("New() with a nil event bus should fail")
+	}
+}
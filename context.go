@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+type loggerContextKey struct{}
+
+// ContextLogger logs with the request scoped values carried by a context.Context
+type ContextLogger interface {
+	DebugContext(ctx context.Context, args ...interface{})
+	DebugfContext(ctx context.Context, format string, args ...interface{})
+	ErrorContext(ctx context.Context, args ...interface{})
+	ErrorfContext(ctx context.Context, format string, args ...interface{})
+	FatalContext(ctx context.Context, args ...interface{})
+	FatalfContext(ctx context.Context, format string, args ...interface{})
+	InfoContext(ctx context.Context, args ...interface{})
+	InfofContext(ctx context.Context, format string, args ...interface{})
+	DPanicContext(ctx context.Context, args ...interface{})
+	DPanicfContext(ctx context.Context, format string, args ...interface{})
+	PanicContext(ctx context.Context, args ...interface{})
+	PanicfContext(ctx context.Context, format string, args ...interface{})
+	WarnContext(ctx context.Context, args ...interface{})
+	WarnfContext(ctx context.Context, format string, args ...interface{})
+}
+
+// IntoContext returns a copy of ctx that carries the logger
+func IntoContext(ctx context.Context, l CorrelationLogger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// FromContext returns the logger stored by IntoContext.
+// A no-op logger is returned when the context does not carry one so callers can always log.
+func FromContext(ctx context.Context) CorrelationLogger {
+	if l, ok := ctx.Value(loggerContextKey{}).(CorrelationLogger); ok {
+		return l
+	}
+	return newNopLogger()
+}
+
+func newNopLogger() *logger {
+	return &logger{
+		log:    zap.NewNop().Sugar(),
+		level:  zap.NewAtomicLevel(),
+		fields: fields{},
+	}
+}
+
+// withContext returns a logger that carries the correlation id, fields and trace ids found in ctx.
+// Values already set on l take precedence over the ones in the context.
+func (l *logger) withContext(ctx context.Context) *logger {
+	if ctx == nil {
+		return l
+	}
+
+	out := l.derive()
+
+	if cl, ok := ctx.Value(loggerContextKey{}).(*logger); ok && cl != l {
+		if out.correlationID == "" {
+			out.correlationID = cl.correlationID
+		}
+		if len(cl.fields) > 0 {
+			// l is usually derived from the context logger and already has its fields
+			fields := make(fields, 0, len(cl.fields)+len(l.fields))
+			for _, f := range cl.fields {
+				if !hasField(l.fields, f.key) {
+					fields = append(fields, f)
+				}
+			}
+			out.fields = append(fields, l.fields...)
+		}
+	}
+
+	if out.correlationID == "" {
+		out.correlationID = correlationIDFromContext(ctx)
+	}
+
+	if sc := spanContextFromContext(ctx); sc.IsValid() {
+		if out.correlationID == "" && out.traceCorrelation {
+			out.correlationID = sc.TraceID().String()
+		}
+		if !hasField(out.fields, traceIDKey) {
+			fields := append(fields{}, out.fields...)
+			for _, f := range traceFields(sc) {
+				fields = append(fields, toField(f))
+			}
+			out.fields = fields
+		}
+	}
+
+	out.setFields(out.correlationID, out.fields)
+	return out
+}
+
+func hasField(fields fields, key string) bool {
+	for _, f := range fields {
+		if f.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// correlationIDFromContext looks for a correlation id in the logger stored in ctx
+// and then for a valid one in the incoming grpc metadata
+func correlationIDFromContext(ctx context.Context) string {
+	if cl, ok := ctx.Value(loggerContextKey{}).(*logger); ok && cl.correlationID != "" {
+		return cl.correlationID
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, val := range md.Get(Key_CorrelationID) {
+		if ValidCorrelationID(val) {
+			return val
+		}
+	}
+	return ""
+}
+
+func (l *logger) DebugContext(ctx context.Context, args ...interface{}) {
+	l.withContext(ctx).Debug(args...)
+}
+
+func (l *logger) DebugfContext(ctx context.Context, format string, args ...interface{}) {
+	l.withContext(ctx).Debugf(format, args...)
+}
+
+func (l *logger) ErrorContext(ctx context.Context, args ...interface{}) {
+	l.withContext(ctx).Error(args...)
+}
+
+func (l *logger) ErrorfContext(ctx context.Context, format string, args ...interface{}) {
+	l.withContext(ctx).Errorf(format, args...)
+}
+
+func (l *logger) FatalContext(ctx context.Context, args ...interface{}) {
+	l.withContext(ctx).Fatal(args...)
+}
+
+func (l *logger) FatalfContext(ctx context.Context, format string, args ...interface{}) {
+	l.withContext(ctx).Fatalf(format, args...)
+}
+
+func (l *logger) InfoContext(ctx context.Context, args ...interface{}) {
+	l.withContext(ctx).Info(args...)
+}
+
+func (l *logger) InfofContext(ctx context.Context, format string, args ...interface{}) {
+	l.withContext(ctx).Infof(format, args...)
+}
+
+func (l *logger) DPanicContext(ctx context.Context, args ...interface{}) {
+	l.withContext(ctx).DPanic(args...)
+}
+
+func (l *logger) DPanicfContext(ctx context.Context, format string, args ...interface{}) {
+	l.withContext(ctx).DPanicf(format, args...)
+}
+
+func (l *logger) PanicContext(ctx context.Context, args ...interface{}) {
+	l.withContext(ctx).Panic(args...)
+}
+
+func (l *logger) PanicfContext(ctx context.Context, format string, args ...interface{}) {
+	l.withContext(ctx).Panicf(format, args...)
+}
+
+func (l *logger) WarnContext(ctx context.Context, args ...interface{}) {
+	l.withContext(ctx).Warn(args...)
+}
+
+func (l *logger) WarnfContext(ctx context.Context, format string, args ...interface{}) {
+	l.withContext(ctx).Warnf(format, args...)
+}
@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestFromContext(t *testing.T) {
+	logr := newNopLogger().WithCorrelationID("from_ctx")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "should pass; with a logger",
+			ctx:  IntoContext(context.Background(), logr),
+			want: "from_ctx",
+		},
+		{
+			name: "should pass; without a logger returns a nop logger",
+			ctx:  context.Background(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FromContext(tt.ctx).(*logger)
+			if !ok {
+				t.Fatal("FromContext() did not return a *logger")
+			}
+			if got.correlationID != tt.want {
+				t.Errorf("FromContext() correlationID = %v, want %v", got.correlationID, tt.want)
+			}
+			// should never panic
+			got.Info("info")
+		})
+	}
+}
+
+func TestLogger_InfoContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func(l CorrelationLogger) context.Context
+		want testLogMsg
+	}{
+		{
+			name: "should pass; correlation id from the context logger",
+			ctx: func(l CorrelationLogger) context.Context {
+				return IntoContext(context.Background(), l.WithCorrelationID("ctx_cor_id"))
+			},
+			want: testLogMsg{Level: "info", Msg: "info", CorrelationId: "ctx_cor_id"},
+		},
+		{
+			name: "should pass; correlation id from the incoming metadata",
+			ctx: func(l CorrelationLogger) context.Context {
+				return metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_CorrelationID, "md_cor_id"))
+			},
+			want: testLogMsg{Level: "info", Msg: "info", CorrelationId: "md_cor_id"},
+		},
+		{
+			name: "should pass; without a correlation id",
+			ctx: func(l CorrelationLogger) context.Context {
+				return context.Background()
+			},
+			want: testLogMsg{Level: "info", Msg: "info"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, writer := io.Pipe()
+			logr, err := New(
+				WithLevel(debug),
+				WithEncoding(jsonEncoder),
+				withWriter(writer),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(chan testLogMsg, 1)
+			go func() {
+				scanner := bufio.NewScanner(reader)
+				for scanner.Scan() {
+					var msg testLogMsg
+					if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+						t.Error(err)
+					}
+					got <- msg
+				}
+			}()
+
+			logr.InfoContext(tt.ctx(logr), "info")
+
+			select {
+			case msg := <-got:
+				if !cmp.Equal(msg, tt.want, cmpopts.IgnoreFields(testLogMsg{}, "Ts")) {
+					t.Errorf("InfoContext() diff: %v", cmp.Diff(msg, tt.want))
+				}
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out waiting for log line")
+			}
+			writer.Close()
+		})
+	}
+}
+
+func TestLogger_InfoContext_sharedFields(t *testing.T) {
+	sb := &syncBuffer{}
+	logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(sb))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := logr.WithField("service", "api").(*logger).WithCorrelationID("c1")
+	ctx := IntoContext(context.Background(), cl)
+	FromContext(ctx).WithField("k", 1).(ContextLogger).InfoContext(ctx, "hello")
+	if err := logr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sb.lines(t, 1)
+	if got := strings.Count(sb.String(), `"service":"api"`); got != 1 {
+		t.Errorf("service written %d times, want once: %s", got, sb.String())
+	}
+	if !strings.Contains(sb.String(), `"k":1`) || !strings.Contains(sb.String(), `"correlation_id":"c1"`) {
+		t.Errorf("line = %s, want k and the correlation id", sb.String())
+	}
+}
+
+func TestLoggingUnaryServerInterceptor_IntoContext(t *testing.T) {
+	logr, err := New(WithLevel(debug))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	middleware := LoggingUnaryServerInterceptor(logr)
+	info := &grpc.UnaryServerInfo{
+		FullMethod: "test/test/test",
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_CorrelationID, "handler_cor_id"))
+
+	var got string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if l, ok := FromContext(ctx).(*logger); ok {
+			got = l.correlationID
+		}
+		return nil, nil
+	}
+
+	if _, err := middleware(ctx, nil, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	if got != "handler_cor_id" {
+		t.Errorf("FromContext() correlationID = %v, want %v", got, "handler_cor_id")
+	}
+}
@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// RepeatCount is the field with how many times a deduplicated entry was repeated,
+	// not counting the first one already written
+	RepeatCount = "repeat_count"
+	// FirstSeen is the field with when a deduplicated entry was first logged
+	FirstSeen = "first_seen"
+	// LastSeen is the field with when a deduplicated entry was last repeated
+	LastSeen = "last_seen"
+
+	// maxDedupKeys is how many distinct entries are tracked at once; the others are written as they come
+	maxDedupKeys = 10000
+)
+
+// dedupKeyEncoderConfig encodes what makes two entries identical: the level, the logger name,
+// the message and the fields
+var dedupKeyEncoderConfig = zapcore.EncoderConfig{
+	LevelKey:       "level",
+	NameKey:        "logger",
+	MessageKey:     "msg",
+	EncodeLevel:    zapcore.LowercaseLevelEncoder,
+	EncodeTime:     zapcore.EpochNanosTimeEncoder,
+	EncodeDuration: zapcore.NanosDurationEncoder,
+	EncodeName:     zapcore.FullNameEncoder,
+}
+
+// repeated is an entry written once in the window and the count of its repeats since
+type repeated struct {
+	core        zapcore.Core
+	ent         zapcore.Entry
+	fields      []zapcore.Field
+	windowEnd   time.Time
+	count       int
+	first, last time.Time
+}
+
+// deduplicator holds the repeated entries until their window ends
+type deduplicator struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*repeated
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newDeduplicator(window time.Duration) *deduplicator {
+	d := &deduplicator{
+		window:  window,
+		now:     time.Now,
+		entries: map[string]*repeated{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+func (d *deduplicator) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.flush(d.now())
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// seen returns true when the entry is a repeat within the window; otherwise the window starts now
+// and the repeats of the previous window, if any, are returned to be written
+func (d *deduplicator) seen(key string, r *repeated) (bool, *repeated) {
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	prev, ok := d.entries[key]
+	if ok && now.Before(prev.windowEnd) {
+		prev.count++
+		prev.last = now
+		return true, nil
+	}
+	if !ok && len(d.entries) >= maxDedupKeys {
+		return false, nil
+	}
+
+	r.windowEnd = now.Add(d.window)
+	r.first = now
+	d.entries[key] = r
+	if ok && prev.count > 0 {
+		return false, prev
+	}
+	return false, nil
+}
+
+// flush writes the repeats of the windows ended by now; every window when now is zero
+func (d *deduplicator) flush(now time.Time) {
+	ended := []*repeated{}
+
+	d.mu.Lock()
+	for key, r := range d.entries {
+		if now.IsZero() || !now.Before(r.windowEnd) {
+			delete(d.entries, key)
+			if r.count > 0 {
+				ended = append(ended, r)
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	for _, r := range ended {
+		r.write()
+	}
+}
+
+// close stops the windows and writes their repeats
+func (d *deduplicator) close() {
+	d.once.Do(func() {
+		close(d.stop)
+	})
+	<-d.done
+	d.flush(time.Time{})
+}
+
+// write writes one entry for the repeats of the window
+func (r *repeated) write() {
+	ent := r.ent
+	ent.Time = r.last
+	fields := append(r.fields[:len(r.fields):len(r.fields)],
+		zap.Int(RepeatCount, r.count),
+		zap.Time(FirstSeen, r.first),
+		zap.Time(LastSeen, r.last),
+	)
+	writeChecked(r.core, ent, fields)
+}
+
+// writeChecked writes the entry through the Check of core so the cores under it still decide
+func writeChecked(core zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) {
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+// dedupCore writes the first of identical entries and counts the repeats until the end of the window
+type dedupCore struct {
+	next  zapcore.Core
+	enc   zapcore.Encoder
+	dedup *deduplicator
+}
+
+func newDedupCore(dedup *deduplicator) newCoreFunc {
+	return func(c zapcore.Core) zapcore.Core {
+		return &dedupCore{
+			next:  c,
+			enc:   zapcore.NewJSONEncoder(dedupKeyEncoderConfig),
+			dedup: dedup,
+		}
+	}
+}
+
+func (c *dedupCore) Enabled(level zapcore.Level) bool {
+	return c.next.Enabled(level)
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &dedupCore{
+		next:  c.next.With(fields),
+		enc:   c.enc.Clone(),
+		dedup: c.dedup,
+	}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level > ErrorLevel {
+		// never held back as the process may be about to panic or exit
+		return c.next.Check(ent, ce)
+	}
+	if c.next.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	keyEnt := ent
+	keyEnt.Time = time.Time{}
+	buf, err := c.enc.EncodeEntry(keyEnt, fields)
+	if err != nil {
+		return err
+	}
+	key := buf.String()
+	buf.Free()
+
+	kept := make([]zapcore.Field, len(fields))
+	copy(kept, fields)
+	repeat, prev := c.dedup.seen(key, &repeated{core: c.next, ent: ent, fields: kept})
+	if prev != nil {
+		prev.write()
+	}
+	if !repeat {
+		writeChecked(c.next, ent, fields)
+	}
+	return nil
+}
+
+func (c *dedupCore) Sync() error {
+	return c.next.Sync()
+}
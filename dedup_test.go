@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithDedup(t *testing.T) {
+	type line struct {
+		Level       string
+		Msg         string
+		RepeatCount float64
+		FirstSeen   string
+		LastSeen    string
+	}
+	tests := []struct {
+		name string
+		// tick moves the clock forward and writes the repeats of the windows it ends
+		log  func(l *logger, tick func(d time.Duration))
+		want []line
+	}{
+		{
+			name: "should pass; repeats are collapsed",
+			log: func(l *logger, tick func(d time.Duration)) {
+				for i := 0; i < 5; i++ {
+					l.Errorf("something=%v", errors.New("boom"))
+					tick(time.Millisecond)
+				}
+			},
+			want: []line{
+				{Level: "error", Msg: "something=boom"},
+				{
+					Level:       "error",
+					Msg:         "something=boom",
+					RepeatCount: 4,
+					FirstSeen:   "2022-01-02T03:04:05.000Z",
+					LastSeen:    "2022-01-02T03:04:05.004Z",
+				},
+			},
+		},
+		{
+			name: "should pass; different fields and levels are not collapsed",
+			log: func(l *logger, tick func(d time.Duration)) {
+				l.WithField("n", 1).Error("failed")
+				l.WithField("n", 2).Error("failed")
+				l.WithField("n", 1).Warn("failed")
+				l.WithField("n", 1).Error("failed")
+			},
+			want: []line{
+				{Level: "error", Msg: "failed"},
+				{Level: "error", Msg: "failed"},
+				{Level: "warn", Msg: "failed"},
+				{
+					Level:       "error",
+					Msg:         "failed",
+					RepeatCount: 1,
+					FirstSeen:   "2022-01-02T03:04:05.000Z",
+					LastSeen:    "2022-01-02T03:04:05.000Z",
+				},
+			},
+		},
+		{
+			name: "should pass; the repeats are written when the window ends",
+			log: func(l *logger, tick func(d time.Duration)) {
+				for i := 0; i < 3; i++ {
+					l.Info("again")
+					tick(time.Second)
+				}
+				tick(time.Hour)
+				l.Info("again")
+			},
+			want: []line{
+				{Level: "info", Msg: "again"},
+				{
+					Level:       "info",
+					Msg:         "again",
+					RepeatCount: 2,
+					FirstSeen:   "2022-01-02T03:04:05.000Z",
+					LastSeen:    "2022-01-02T03:04:07.000Z",
+				},
+				{Level: "info", Msg: "again"},
+			},
+		},
+		{
+			name: "should pass; a new window writes the repeats of the previous one",
+			log: func(l *logger, tick func(d time.Duration)) {
+				l.Info("again")
+				l.Info("again")
+				tick(30 * time.Minute)
+				l.Info("other")
+				tick(30 * time.Minute)
+				l.Info("again")
+			},
+			want: []line{
+				{Level: "info", Msg: "again"},
+				{Level: "info", Msg: "other"},
+				{
+					Level:       "info",
+					Msg:         "again",
+					RepeatCount: 1,
+					FirstSeen:   "2022-01-02T03:04:05.000Z",
+					LastSeen:    "2022-01-02T03:04:05.000Z",
+				},
+				{Level: "info", Msg: "again"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &syncBuffer{}
+			logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(sb), WithDedup(time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the ticker of the window never fires in the test so only tick flushes
+			dedup := logr.outputs.dedup
+			now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+			dedup.now = func() time.Time { return now }
+			tick := func(d time.Duration) {
+				now = now.Add(d)
+				dedup.flush(now)
+			}
+
+			tt.log(logr, tick)
+			if err := logr.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := []line{}
+			for _, l := range sb.lines(t, len(tt.want)) {
+				count, _ := l[RepeatCount].(float64)
+				first, _ := l[FirstSeen].(string)
+				last, _ := l[LastSeen].(string)
+				got = append(got, line{
+					Level:       l["level"].(string),
+					Msg:         l["msg"].(string),
+					RepeatCount: count,
+					FirstSeen:   first,
+					LastSeen:    last,
+				})
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("lines mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithDedup_invalid(t *testing.T) {
+	if _, err := New(WithDedup(0)); err == nil {
+		t.Error("New() with a zero dedup window should fail")
+	}
+}
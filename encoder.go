@@ -11,17 +11,66 @@ import (
 var (
 	errNoEncoderNameSpecified = errors.New("no encoder name specified")
 
-	_encoderNameToConstructor = map[string]func(zapcore.EncoderConfig) (zapcore.Encoder, error){
+	_encoderNameToConstructor = map[string]EncoderConstructor{
 		"console": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
 			return zapcore.NewConsoleEncoder(encoderConfig), nil
 		},
 		"json": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
 			return zapcore.NewJSONEncoder(encoderConfig), nil
 		},
+		"logfmt": func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return NewLogfmtEncoder(encoderConfig), nil
+		},
 	}
 	_encoderMutex sync.RWMutex
 )
 
+// EncoderConstructor builds an encoder from the logger's EncoderConfig
+type EncoderConstructor = func(zapcore.EncoderConfig) (zapcore.Encoder, error)
+
+// RegisterEncoder registers an encoder constructor under name so it can be
+// used with WithEncoding, WithWriterEncoding and the --log-encoding flag.
+// It returns an error if the name is empty or already registered.
+func RegisterEncoder(name string, constructor EncoderConstructor) error {
+	_encoderMutex.Lock()
+	defer _encoderMutex.Unlock()
+	if name == "" {
+		return errNoEncoderNameSpecified
+	}
+	if constructor == nil {
+		return fmt.Errorf("no constructor given for encoder %q", name)
+	}
+	if _, ok := _encoderNameToConstructor[name]; ok {
+		return fmt.Errorf("encoder already registered for name %q", name)
+	}
+	_encoderNameToConstructor[name] = constructor
+	return nil
+}
+
+// zapEncoder reports if zap builds the encoder itself; the others are only known by this package
+// so zap's global registry, which other packages may fill, is left alone
+func zapEncoder(name string) bool {
+	return name == jsonEncoder || name == consoleEncoder
+}
+
+// sinkCloser closes an output opened by zap.Open
+type sinkCloser struct {
+	zapcore.WriteSyncer
+	close func()
+}
+
+func (s sinkCloser) Close() error {
+	s.close()
+	return nil
+}
+
+func isEncoderRegistered(name string) bool {
+	_encoderMutex.RLock()
+	defer _encoderMutex.RUnlock()
+	_, ok := _encoderNameToConstructor[name]
+	return ok
+}
+
 func newEncoder(name string, encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
 	if encoderConfig.TimeKey != "" && encoderConfig.EncodeTime == nil {
 		return nil, fmt.Errorf("missing EncodeTime in EncoderConfig")
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRegisterEncoder(t *testing.T) {
+	constructor := func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return zapcore.NewJSONEncoder(cfg), nil
+	}
+
+	tests := []struct {
+		name        string
+		encoder     string
+		constructor EncoderConstructor
+		wantErr     bool
+	}{
+		{
+			name:        "should pass",
+			encoder:     "test-register",
+			constructor: constructor,
+		},
+		{
+			name:        "should fail; already registered",
+			encoder:     "test-register",
+			constructor: constructor,
+			wantErr:     true,
+		},
+		{
+			name:        "should fail; builtin encoder",
+			encoder:     jsonEncoder,
+			constructor: constructor,
+			wantErr:     true,
+		},
+		{
+			name:        "should fail; no name",
+			constructor: constructor,
+			wantErr:     true,
+		},
+		{
+			name:    "should fail; no constructor",
+			encoder: "test-register-nil",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterEncoder(tt.encoder, tt.constructor); (err != nil) != tt.wantErr {
+				t.Errorf("RegisterEncoder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	enum := NewLogEncodingEnum()
+	if err := enum.Set("test-register"); err != nil {
+		t.Fatalf("LogEncodingEnum.Set() error = %v", err)
+	}
+	if got := enum.String(); got != "test-register" {
+		t.Errorf("LogEncodingEnum.String() = %v, want %v", got, "test-register")
+	}
+	if _, err := New(WithEncoding("test-register")); err != nil {
+		t.Errorf("New() error = %v", err)
+	}
+}
+
+func TestNew_logfmtRegisteredWithZap(t *testing.T) {
+	// another package registering logfmt with zap does not change ours
+	err := zap.RegisterEncoder(logfmtEncoder, func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return zapcore.NewJSONEncoder(cfg), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	logr, err := New(WithEncoding(logfmtEncoder), WithLogFile(logFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logr.Info("hello")
+	if err := logr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "msg=hello") {
+		t.Errorf("log file = %q, want a logfmt line", b)
+	}
+}
+
+func TestWithWriterEncoding(t *testing.T) {
+	console, jsonOut := &syncBuffer{}, &syncBuffer{}
+
+	logr, err := New(
+		WithEncoding(consoleEncoder),
+		WithInitialFields(map[string]interface{}{"service": "test"}),
+		WithWriterEncoding(console, consoleEncoder),
+		WithWriterEncoding(jsonOut, jsonEncoder),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logr.WithCorrelationID("cor_id").Info("hello")
+
+	got := jsonOut.lines(t, 1)[0]
+	if got["msg"] != "hello" || got["correlation_id"] != "cor_id" || got["service"] != "test" {
+		t.Errorf("json output = %v", got)
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for console.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	line := console.String()
+	if !strings.Contains(line, "\tinfo\thello\t") || json.Valid([]byte(line)) {
+		t.Errorf("console output = %q", line)
+	}
+
+	if _, err := New(WithWriterEncoding(console, "unknown")); err == nil {
+		t.Error("New() with an unknown encoding should fail")
+	}
+}
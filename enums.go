@@ -15,7 +15,7 @@ const (
 	warn     = "warn"
 	errorStr = "error"
 	dpanic   = "dpanic"
-	panic    = "panic"
+	panicStr = "panic"
 	fatal    = "fatal"
 )
 
@@ -33,7 +33,7 @@ func NewLogLevelEnum() *LogLevelEnum {
 			warn,
 			errorStr,
 			dpanic,
-			panic,
+			panicStr,
 			fatal,
 		},
 	}
@@ -45,7 +45,7 @@ var LogLevelEnum_values = map[string]int{
 	warn:     int(WarnLevel),
 	errorStr: int(ErrorLevel),
 	dpanic:   int(DPanicLevel),
-	panic:    int(PanicLevel),
+	panicStr: int(PanicLevel),
 	fatal:    int(FatalLevel),
 }
 
@@ -55,7 +55,7 @@ var LogLevelEnum_keys = map[int]string{
 	int(WarnLevel):   warn,
 	int(ErrorLevel):  errorStr,
 	int(DPanicLevel): dpanic,
-	int(PanicLevel):  panic,
+	int(PanicLevel):  panicStr,
 	int(FatalLevel):  fatal,
 }
 
@@ -159,8 +159,10 @@ type LogEncoding int
 const (
 	JSON LogEncoding = iota
 	Console
+	Logfmt
 	jsonEncoder    = "json"
 	consoleEncoder = "console"
+	logfmtEncoder  = "logfmt"
 )
 
 func (e LogEncoding) String() string {
@@ -169,6 +171,8 @@ func (e LogEncoding) String() string {
 		return jsonEncoder
 	case Console:
 		return consoleEncoder
+	case Logfmt:
+		return logfmtEncoder
 	}
 	return ""
 }
@@ -179,6 +183,8 @@ func ParseLogEncoding(env string) LogEncoding {
 		return JSON
 	case consoleEncoder:
 		return Console
+	case logfmtEncoder:
+		return Logfmt
 	}
 	return Console
 }
@@ -194,6 +200,7 @@ func NewLogEncodingEnum() *LogEncodingEnum {
 		Enum: []string{
 			jsonEncoder,
 			consoleEncoder,
+			logfmtEncoder,
 		},
 		Default: consoleEncoder,
 	}
@@ -202,11 +209,13 @@ func NewLogEncodingEnum() *LogEncodingEnum {
 var LogEncodingEnum_values = map[string]LogEncoding{
 	jsonEncoder:    JSON,
 	consoleEncoder: Console,
+	logfmtEncoder:  Logfmt,
 }
 
 var LogEncodingEnum_keys = map[LogEncoding]string{
 	JSON:    jsonEncoder,
 	Console: consoleEncoder,
+	Logfmt:  logfmtEncoder,
 }
 
 func (e *LogEncodingEnum) Set(value string) error {
@@ -214,11 +223,19 @@ func (e *LogEncodingEnum) Set(value string) error {
 		e.selected = val.String()
 		return nil
 	}
+	// encoders added with RegisterEncoder
+	if isEncoderRegistered(value) {
+		e.selected = value
+		return nil
+	}
 
 	return fmt.Errorf("allowed values are %s", strings.Join(e.Enum, ", "))
 }
 
 func (e *LogEncodingEnum) String() string {
+	if _, ok := LogEncodingEnum_values[strings.ToLower(e.selected)]; !ok && isEncoderRegistered(e.selected) {
+		return e.selected
+	}
 	if val, ok := LogEncodingEnum_keys[ParseLogEncoding(e.selected)]; ok {
 		return val
 	}
@@ -1,14 +1,29 @@
 package event
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
+var (
+	// ErrNotSubscribed is returned by Unsubscribe when the pointer is not a subscriber of the bus
+	ErrNotSubscribed = errors.New("not subscribed")
+	// ErrSlowSubscriber is the error of a subscriber disconnected by the Disconnect policy
+	ErrSlowSubscriber = errors.New("slow subscriber disconnected")
+)
+
+// DefaultBufferSize is how many messages a subscriber buffers when New is not given WithBufferSize
+const DefaultBufferSize = 64
+
 type FactoryBus interface {
 	Subscriber
+	TopicSubscriber
 	Unsubscriber
 	Publisher
+	TopicPublisher
 }
 
 type FactoryEvent interface {
@@ -16,6 +31,7 @@ type FactoryEvent interface {
 	Data() chan []byte
 	Done() <-chan struct{}
 	Error() error
+	Dropped() uint64
 }
 
 type Unsubscriber interface {
@@ -30,75 +46,191 @@ type Publisher interface {
 	Publish(Data []byte)
 }
 
+// Policy decides what Publish does when the buffer of a subscriber is full
+type Policy int
+
+const (
+	// Block waits for the subscriber to make room, so every message is delivered
+	Block Policy = iota
+	// Drop skips the message for that subscriber and counts it in Dropped
+	Drop
+	// Disconnect closes the subscriber with ErrSlowSubscriber
+	Disconnect
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case Drop:
+		return "drop"
+	case Disconnect:
+		return "disconnect"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+type config struct {
+	bufferSize int
+	policy     Policy
+}
+
+type Option interface {
+	applyOption(*config)
+}
+
+type applyOptionFunc func(*config)
+
+func (f applyOptionFunc) applyOption(c *config) {
+	f(c)
+}
+
+// WithBufferSize sets how many messages each subscriber buffers
+func WithBufferSize(size int) Option {
+	return applyOptionFunc(func(c *config) {
+		if size >= 0 {
+			c.bufferSize = size
+		}
+	})
+}
+
+// WithPolicy sets what happens when a subscriber is too slow to keep up; Block by default
+func WithPolicy(policy Policy) Option {
+	return applyOptionFunc(func(c *config) {
+		c.policy = policy
+	})
+}
+
 type DataChannelSlice []FactoryEvent
 
+// Event delivers every published message to the subscribers in the order it was published
 type Event struct {
 	sync.RWMutex
 	subscribers DataChannelSlice
+	config      config
+	closed      bool
 }
 
-func New() *Event {
+func New(opts ...Option) *Event {
+	c := config{
+		bufferSize: DefaultBufferSize,
+		policy:     Block,
+	}
+	for _, opt := range opts {
+		opt.applyOption(&c)
+	}
 	return &Event{
 		subscribers: DataChannelSlice{},
+		config:      c,
 	}
 }
 
 type iterator struct {
-	topic string
-	done  chan struct{}
-	ch    chan []byte
-	err   chan error
-	unsub Unsubscriber
+	// topic is the pattern of the subscription; empty for Subscribe
+	topic   string
+	done    chan struct{}
+	ch      chan []byte
+	unsub   Unsubscriber
+	dropped uint64
+	// closing is set by the first of Close and disconnect
+	closing int32
+
+	mu  sync.Mutex
+	err error
 }
 
 func NewIterator(unsub Unsubscriber) *iterator {
+	return newIterator(unsub, 0)
+}
+
+func newIterator(unsub Unsubscriber, bufferSize int) *iterator {
 	return &iterator{
 		done:  make(chan struct{}),
-		ch:    make(chan []byte),
-		err:   make(chan error),
+		ch:    make(chan []byte, bufferSize),
 		unsub: unsub,
 	}
 }
 
+// Done is closed when the subscriber is closed or disconnected
 func (i *iterator) Done() <-chan struct{} {
 	return i.done
 }
 
+// Data returns the messages; it is closed once the subscriber is closed or disconnected
 func (i *iterator) Data() chan []byte {
 	return i.ch
 }
 
+// Error returns why the subscriber was disconnected
 func (i *iterator) Error() error {
-	select {
-	case err := <-i.err:
-		return err
-	default:
-		return nil
-	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.err
 }
 
+// Dropped returns how many messages were skipped by the Drop policy
+func (i *iterator) Dropped() uint64 {
+	return atomic.LoadUint64(&i.dropped)
+}
+
+// Close unsubscribes and closes the data channel; it can be called more than once
 func (i *iterator) Close() error {
-	select {
-	case i.done <- struct{}{}:
-	default:
+	if !atomic.CompareAndSwapInt32(&i.closing, 0, 1) {
+		return i.Error()
 	}
-	close(i.ch)
-	err := i.Error()
-	close(i.err)
-	close(i.done)
 
+	// publishers blocked on this subscriber give up once done is closed
+	close(i.done)
 	uerr := i.unsub.Unsubscribe(unsafe.Pointer(i))
-	if err != nil {
-		return err
+	// no publisher can send anymore once the iterator is unsubscribed
+	close(i.ch)
+
+	if errors.Is(uerr, ErrNotSubscribed) {
+		// the bus was closed first
+		uerr = nil
 	}
 	return uerr
 }
 
+// disconnect closes an iterator that the bus already removed from its subscribers
+func (i *iterator) disconnect(err error) {
+	if !atomic.CompareAndSwapInt32(&i.closing, 0, 1) {
+		return
+	}
+	i.mu.Lock()
+	i.err = err
+	i.mu.Unlock()
+	close(i.done)
+	close(i.ch)
+}
+
+// Subscribe adds a subscriber to every message; the subscriber of a closed bus is returned closed
 func (e *Event) Subscribe() FactoryEvent {
+	return e.subscribe("")
+}
+
+// SubscribeTopic adds a subscriber to the topics matching the pattern.
+// The subscriber of an invalid pattern is returned closed with ErrInvalidTopic.
+func (e *Event) SubscribeTopic(pattern string) FactoryEvent {
+	if !validPattern(pattern) {
+		iter := newIterator(e, 0)
+		iter.disconnect(ErrInvalidTopic)
+		return iter
+	}
+	return e.subscribe(pattern)
+}
+
+func (e *Event) subscribe(pattern string) FactoryEvent {
 	e.Lock()
 	defer e.Unlock()
 
-	iter := NewIterator(e)
+	iter := newIterator(e, e.config.bufferSize)
+	iter.topic = pattern
+	if e.closed {
+		iter.disconnect(nil)
+		return iter
+	}
 	e.subscribers = append(e.subscribers, iter)
 
 	return iter
@@ -108,35 +240,86 @@ func (e *Event) Unsubscribe(iter unsafe.Pointer) error {
 	e.Lock()
 	defer e.Unlock()
 
-	var loc int
-
-	val := e.subscribers
-
-	for i, v := range val {
+	for i, v := range e.subscribers {
 		val, ok := v.(*iterator)
 		if ok && unsafe.Pointer(val) == iter {
-			loc = i
+			e.subscribers = remove(e.subscribers, i)
+			return nil
 		}
 	}
-
-	e.subscribers = remove(val, loc)
-	return nil
+	return ErrNotSubscribed
 }
 
 func remove(slice []FactoryEvent, s int) []FactoryEvent {
-	return append(slice[:s], slice[s+1:]...)
+	// copy so publishers holding the old slice are not affected
+	out := make([]FactoryEvent, 0, len(slice)-1)
+	out = append(out, slice[:s]...)
+	return append(out, slice[s+1:]...)
 }
 
+// Publish delivers data to the subscribers made by Subscribe following the policy of the bus.
+// Publishes are serialized so every subscriber gets the messages in the same order.
 func (e *Event) Publish(data []byte) {
-	e.RLock()
-	defer e.RUnlock()
-	// this is done because the slices refer to same array even though they are passed by value
-	// thus we are creating a new slice with our elements thus preserve locking correctly.
-	channels := append(DataChannelSlice{}, e.subscribers...)
-	go func(data []byte, dataChannelSlices DataChannelSlice) {
-		for _, itr := range dataChannelSlices {
-			itr.Data() <- data
+	e.PublishTopic("", data)
+}
+
+// PublishTopic delivers data to the subscribers whose pattern matches the topic
+// and to the ones made by Subscribe
+func (e *Event) PublishTopic(topic string, data []byte) {
+	e.Lock()
+	defer e.Unlock()
+
+	var slow []int
+	for idx, sub := range e.subscribers {
+		itr, ok := sub.(*iterator)
+		if !ok || !matchTopic(itr.topic, topic) {
+			continue
 		}
-	}(data, channels)
 
+		select {
+		case <-itr.done:
+			continue
+		case itr.ch <- data:
+			continue
+		default:
+		}
+
+		switch e.config.policy {
+		case Drop:
+			atomic.AddUint64(&itr.dropped, 1)
+		case Disconnect:
+			slow = append(slow, idx)
+		default:
+			select {
+			case <-itr.done:
+			case itr.ch <- data:
+			}
+		}
+	}
+
+	// remove from the last so the indexes stay valid
+	for j := len(slow) - 1; j >= 0; j-- {
+		itr := e.subscribers[slow[j]].(*iterator)
+		e.subscribers = remove(e.subscribers, slow[j])
+		itr.disconnect(ErrSlowSubscriber)
+	}
+}
+
+// Close disconnects the subscribers, which still read the messages they buffered,
+// and makes Publish a no-op
+func (e *Event) Close() error {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	for _, sub := range e.subscribers {
+		if itr, ok := sub.(*iterator); ok {
+			itr.disconnect(nil)
+		}
+	}
+	e.subscribers = DataChannelSlice{}
+	return nil
 }
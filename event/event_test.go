@@ -0,0 +1,206 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func collect(t *testing.T, itr FactoryEvent, n int) []string {
+	t.Helper()
+
+	out := []string{}
+	timeout := time.After(5 * time.Second)
+	for len(out) < n {
+		select {
+		case data, ok := <-itr.Data():
+			if !ok {
+				return out
+			}
+			out = append(out, string(data))
+		case <-timeout:
+			t.Fatalf("timed out after %d of %d messages", len(out), n)
+		}
+	}
+	return out
+}
+
+func messages(from, to int) []string {
+	out := []string{}
+	for i := from; i < to; i++ {
+		out = append(out, fmt.Sprint(i))
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEvent_Publish(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		want        []string
+		wantDropped uint64
+		wantErr     error
+	}{
+		{
+			name: "should pass; block delivers everything in order",
+			want: messages(0, 10),
+		},
+		{
+			name:        "should pass; drop skips the messages over the buffer",
+			opts:        []Option{WithPolicy(Drop)},
+			want:        messages(0, 2),
+			wantDropped: 8,
+		},
+		{
+			name:    "should pass; disconnect closes the slow subscriber",
+			opts:    []Option{WithPolicy(Disconnect)},
+			want:    messages(0, 2),
+			wantErr: ErrSlowSubscriber,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(append([]Option{WithBufferSize(2)}, tt.opts...)...)
+			slow := e.Subscribe()
+			fast := e.Subscribe()
+
+			// slow reads on its own while blocking and only after everything is published otherwise
+			var got []string
+			var wg sync.WaitGroup
+			read := func() {
+				defer wg.Done()
+				got = collect(t, slow, len(tt.want))
+			}
+			wg.Add(1)
+			if tt.opts == nil {
+				go read()
+			}
+
+			// fast reads every message right after it is published
+			var fastGot []string
+			for _, msg := range messages(0, 10) {
+				e.Publish([]byte(msg))
+				fastGot = append(fastGot, collect(t, fast, 1)...)
+			}
+
+			if tt.opts != nil {
+				read()
+			}
+			wg.Wait()
+
+			if !equal(got, tt.want) {
+				t.Errorf("slow got %v, want %v", got, tt.want)
+			}
+			if want := messages(0, 10); !equal(fastGot, want) {
+				t.Errorf("fast got %v, want %v", fastGot, want)
+			}
+			if got := slow.Dropped(); got != tt.wantDropped {
+				t.Errorf("Dropped() = %v, want %v", got, tt.wantDropped)
+			}
+			if err := slow.Error(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Error() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if _, ok := <-slow.Data(); ok {
+					t.Error("the data channel of a disconnected subscriber is open")
+				}
+			}
+			if err := slow.Close(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Close() error = %v, want %v", err, tt.wantErr)
+			}
+			fast.Close()
+		})
+	}
+}
+
+func TestEvent_Close_unblocks_publish(t *testing.T) {
+	e := New(WithBufferSize(1))
+	itr := e.Subscribe()
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		e.Publish([]byte("1"))
+		// blocks on the full buffer until the subscriber closes
+		e.Publish([]byte("2"))
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := itr.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish is still blocked")
+	}
+
+	// closing twice is fine
+	if err := itr.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+}
+
+func TestEvent_Unsubscribe(t *testing.T) {
+	e := New()
+	first := e.Subscribe()
+	second := e.Subscribe()
+
+	other := NewIterator(e)
+	if err := e.Unsubscribe(unsafe.Pointer(other)); !errors.Is(err, ErrNotSubscribed) {
+		t.Errorf("Unsubscribe() error = %v, want %v", err, ErrNotSubscribed)
+	}
+	if got := len(e.subscribers); got != 2 {
+		t.Fatalf("unsubscribing an unknown pointer removed a subscriber, %d left", got)
+	}
+
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+	e.Publish([]byte("only first"))
+	if got := collect(t, first, 1); !equal(got, []string{"only first"}) {
+		t.Errorf("first got %v", got)
+	}
+	if got := len(e.subscribers); got != 1 {
+		t.Errorf("%d subscribers left, want 1", got)
+	}
+}
+
+func TestEvent_Close(t *testing.T) {
+	e := New()
+	itr := e.Subscribe()
+	e.Publish([]byte("buffered"))
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	e.Publish([]byte("after close"))
+
+	// the buffered message is still read before the channel ends
+	if got := collect(t, itr, 2); !equal(got, []string{"buffered"}) {
+		t.Errorf("got %v, want [buffered]", got)
+	}
+	if err := itr.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	late := e.Subscribe()
+	if _, ok := <-late.Data(); ok {
+		t.Error("subscribing to a closed bus returned an open subscriber")
+	}
+}
@@ -0,0 +1,68 @@
+package event
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidTopic is the error of a subscriber whose pattern is not valid
+var ErrInvalidTopic = errors.New("invalid topic")
+
+const (
+	// wildcardSegment matches exactly one segment of a topic
+	wildcardSegment = "*"
+	// wildcardRest matches the rest of a topic, including nothing; it can only be the last segment
+	wildcardRest = ">"
+)
+
+type TopicSubscriber interface {
+	// SubscribeTopic receives the messages published on the topics matching the pattern.
+	// Topics are made of segments separated by dots, like "error.api.http";
+	// "*" matches one segment and a trailing ">" matches the rest of the topic.
+	SubscribeTopic(pattern string) FactoryEvent
+}
+
+type TopicPublisher interface {
+	PublishTopic(topic string, data []byte)
+}
+
+// validPattern reports if the pattern has no empty segment and ">" only as the last one
+func validPattern(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+	segments := strings.Split(pattern, ".")
+	for i, s := range segments {
+		if s == "" {
+			return false
+		}
+		if s == wildcardRest && i != len(segments)-1 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTopic reports if the topic matches the pattern; the empty pattern matches every topic
+func matchTopic(pattern, topic string) bool {
+	if pattern == "" {
+		return true
+	}
+	for {
+		var p, t string
+		p, pattern, _ = strings.Cut(pattern, ".")
+		if p == wildcardRest {
+			return true
+		}
+		if topic == "" {
+			return false
+		}
+		t, topic, _ = strings.Cut(topic, ".")
+		if p != wildcardSegment && p != t {
+			return false
+		}
+		if pattern == "" {
+			return topic == ""
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package event
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_matchTopic(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{name: "should pass; everything", pattern: "", topic: "error.api", want: true},
+		{name: "should pass; exact", pattern: "error.api", topic: "error.api", want: true},
+		{name: "should pass; one segment", pattern: "*.api", topic: "info.api", want: true},
+		{name: "should pass; the rest", pattern: "error.>", topic: "error.api.http", want: true},
+		{name: "should pass; the rest is empty", pattern: "error.>", topic: "error", want: true},
+		{name: "should pass; both wildcards", pattern: "*.api.>", topic: "warn.api.http", want: true},
+		{name: "should fail; other segment", pattern: "error.api", topic: "error.db", want: false},
+		{name: "should fail; longer topic", pattern: "error", topic: "error.api", want: false},
+		{name: "should fail; shorter topic", pattern: "*.api", topic: "error", want: false},
+		{name: "should fail; one segment is not the rest", pattern: "error.*", topic: "error.api.http", want: false},
+		{name: "should fail; untargeted message", pattern: "error.>", topic: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchTopic(tt.pattern, tt.topic); got != tt.want {
+				t.Errorf("matchTopic(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvent_SubscribeTopic(t *testing.T) {
+	e := New()
+	all := e.Subscribe()
+	errs := e.SubscribeTopic("error.>")
+	api := e.SubscribeTopic("*.api")
+
+	for _, invalid := range []string{"", "error..api", ">.error"} {
+		itr := e.SubscribeTopic(invalid)
+		if err := itr.Error(); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("SubscribeTopic(%q) error = %v, want %v", invalid, err, ErrInvalidTopic)
+		}
+	}
+
+	e.PublishTopic("info.api", []byte("1"))
+	e.PublishTopic("error", []byte("2"))
+	e.PublishTopic("error.api", []byte("3"))
+	e.Publish([]byte("4"))
+	e.Close()
+
+	tests := []struct {
+		name string
+		itr  FactoryEvent
+		want []string
+	}{
+		{name: "should pass; everything", itr: all, want: []string{"1", "2", "3", "4"}},
+		{name: "should pass; errors", itr: errs, want: []string{"2", "3"}},
+		{name: "should pass; api", itr: api, want: []string{"1", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collect(t, tt.itr, len(tt.want)+1); !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,6 +20,21 @@ func NewApp() *cli.App {
 				logger.WithLevel(c.String(flags.LogLevel)),
 				logger.WithLogStacktrace(c.Bool(flags.LogStacktrace)),
 				logger.WithEncoding(c.String(flags.LogEncoding)),
+				logger.WithLogFileMaxSize(c.Int(flags.LogFileMaxSize)),
+				logger.WithLogFileMaxAge(c.Duration(flags.LogFileMaxAge)),
+				logger.WithLogFileMaxBackups(c.Int(flags.LogFileMaxBackups)),
+				logger.WithLogFileCompress(c.Bool(flags.LogFileCompress)),
+				logger.WithLogFileLocalTime(c.Bool(flags.LogFileLocalTime)),
+				logger.WithSampling(c.Int(flags.LogSamplingInitial), c.Int(flags.LogSamplingThereafter), c.Duration(flags.LogSamplingTick)),
+				logger.WithRateLimit(c.Float64(flags.LogRateLimit), c.Int(flags.LogRateLimitBurst), c.Duration(flags.LogRateLimitSummaryInterval)),
+			}
+
+			if c.Bool(flags.LogLevelSignals) {
+				opts = append(opts, logger.WithLevelSignals())
+			}
+
+			for _, logFile := range c.StringSlice(flags.LogFile) {
+				opts = append(opts, logger.WithLogFile(logFile))
 			}
 
 			if encoding := c.String(flags.LogEncoding); encoding != "" {
@@ -30,6 +45,7 @@ func NewApp() *cli.App {
 			if err != nil {
 				log.Fatal(err)
 			}
+			defer logr.Close()
 			logr.Debug("test", "more")
 			logr.Debug("1", "2 ")
 			logr.Debugf("key=%s", "value")
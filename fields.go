@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TypedField is a Field made by one of the typed constructors like String or Int64.
+// It is written as is instead of going through the reflection of zap.Any like KV.
+// Given to WithFields it is still boxed in the Field interface, an allocation per field;
+// WithTypedFields takes it as is so the allocations of the derived logger do not grow with the fields.
+type TypedField struct {
+	field zapcore.Field
+}
+
+// Key returns the name of the field
+func (t TypedField) Key() string {
+	return t.field.Key
+}
+
+// Value returns the value of the field
+func (t TypedField) Value() interface{} {
+	f := t.field
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.Int64Type:
+		return f.Integer
+	case zapcore.BoolType:
+		return f.Integer == 1
+	case zapcore.DurationType:
+		return time.Duration(f.Integer)
+	case zapcore.TimeType:
+		ts := time.Unix(0, f.Integer)
+		if loc, ok := f.Interface.(*time.Location); ok {
+			return ts.In(loc)
+		}
+		return ts
+	case zapcore.SkipType:
+		return nil
+	default:
+		return f.Interface
+	}
+}
+
+// String is a string field
+func String(key, value string) TypedField {
+	return TypedField{zap.String(key, value)}
+}
+
+// Int64 is an int64 field
+func Int64(key string, value int64) TypedField {
+	return TypedField{zap.Int64(key, value)}
+}
+
+// Bool is a bool field
+func Bool(key string, value bool) TypedField {
+	return TypedField{zap.Bool(key, value)}
+}
+
+// Duration is a time.Duration field written with the duration encoder of the logger
+func Duration(key string, value time.Duration) TypedField {
+	return TypedField{zap.Duration(key, value)}
+}
+
+// Time is a time.Time field written with the time encoder of the logger
+func Time(key string, value time.Time) TypedField {
+	return TypedField{zap.Time(key, value)}
+}
+
+// Err is the error field; nothing is written when err is nil
+func Err(err error) TypedField {
+	return TypedField{zap.Error(err)}
+}
+
+// Stringer is a field written with the String method of value, called only when the entry is written
+func Stringer(key string, value fmt.Stringer) TypedField {
+	return TypedField{zap.Stringer(key, value)}
+}
+
+// Object is a field written as a nested object by the MarshalLogObject method of value
+func Object(key string, value zapcore.ObjectMarshaler) TypedField {
+	return TypedField{zap.Object(key, value)}
+}
+
+// Array is a field written as a list by the MarshalLogArray method of value
+func Array(key string, value zapcore.ArrayMarshaler) TypedField {
+	return TypedField{zap.Array(key, value)}
+}
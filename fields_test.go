@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testStringer struct{}
+
+func (testStringer) String() string {
+	return "stringer"
+}
+
+type testObject struct {
+	name string
+}
+
+func (o testObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", o.name)
+	return nil
+}
+
+type testArray []string
+
+func (a testArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, s := range a {
+		enc.AppendString(s)
+	}
+	return nil
+}
+
+// newDiscardLogger writes json to io.Discard without the pipe of WithWriters
+func newDiscardLogger() *logger {
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	zl := zap.New(zapcore.NewCore(enc, zapcore.AddSync(io.Discard), zapcore.DebugLevel))
+	return &logger{log: zl.Sugar(), base: zl, level: zap.NewAtomicLevel()}
+}
+
+func TestTypedField(t *testing.T) {
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := errors.New("failed")
+
+	tests := []struct {
+		name      string
+		field     TypedField
+		wantKey   string
+		wantValue interface{}
+		wantJSON  interface{}
+	}{
+		{name: "should pass; string", field: String("s", "v"), wantKey: "s", wantValue: "v", wantJSON: "v"},
+		{name: "should pass; int64", field: Int64("i", 42), wantKey: "i", wantValue: int64(42), wantJSON: float64(42)},
+		{name: "should pass; bool", field: Bool("b", true), wantKey: "b", wantValue: true, wantJSON: true},
+		{name: "should pass; duration", field: Duration("d", time.Second), wantKey: "d", wantValue: time.Second, wantJSON: float64(1)},
+		{name: "should pass; time", field: Time("t", ts), wantKey: "t", wantValue: ts, wantJSON: "2022-01-02T03:04:05.000Z"},
+		{name: "should pass; error", field: Err(err), wantKey: "error", wantValue: err, wantJSON: "failed"},
+		{name: "should pass; stringer", field: Stringer("st", testStringer{}), wantKey: "st", wantValue: testStringer{}, wantJSON: "stringer"},
+		{name: "should pass; object", field: Object("o", testObject{"joe"}), wantKey: "o", wantValue: testObject{"joe"}, wantJSON: map[string]interface{}{"name": "joe"}},
+		{name: "should pass; array", field: Array("a", testArray{"x", "y"}), wantKey: "a", wantValue: testArray{"x", "y"}, wantJSON: []interface{}{"x", "y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.field.Key(); got != tt.wantKey {
+				t.Errorf("Key() = %v, want %v", got, tt.wantKey)
+			}
+			if diff := cmp.Diff(tt.wantValue, tt.field.Value(), cmp.AllowUnexported(testObject{}), cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+				t.Errorf("Value() mismatch (-want +got):\n%s", diff)
+			}
+
+			sb := &syncBuffer{}
+			logr, err := New(WithEncoding(jsonEncoder), WithWriters(sb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			logr.WithCorrelationID("cor_id").WithFields(tt.field).Info("typed")
+			if err := logr.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			line := sb.lines(t, 1)[0]
+			if diff := cmp.Diff(tt.wantJSON, line[tt.wantKey]); diff != "" {
+				t.Errorf("written value mismatch (-want +got):\n%s", diff)
+			}
+			if line[CorrelationID] != "cor_id" {
+				t.Errorf("%s = %v, want cor_id", CorrelationID, line[CorrelationID])
+			}
+		})
+	}
+}
+
+func TestTypedField_allocs(t *testing.T) {
+	plain := newDiscardLogger()
+	typed := plain.
+		WithCorrelationID("cor_id").
+		WithFields(String("s", "v"), Int64("i", 42), Duration("d", time.Second), Bool("b", true))
+
+	want := testing.AllocsPerRun(100, func() {
+		plain.Info("typed")
+	})
+	got := testing.AllocsPerRun(100, func() {
+		typed.Info("typed")
+	})
+	if got > want {
+		t.Errorf("allocations with typed fields = %v, want at most %v like without fields", got, want)
+	}
+}
+
+func TestLogger_WithTypedFields(t *testing.T) {
+	sb := &syncBuffer{}
+	logr, err := New(WithEncoding(jsonEncoder), WithWriters(sb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logr.WithCorrelationID("cor_id").WithTypedFields(String("s", "v"), Int64("i", 42)).Info("typed")
+	if err := logr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	line := sb.lines(t, 1)[0]
+	want := map[string]interface{}{CorrelationID: "cor_id", "s": "v", "i": float64(42)}
+	for key, value := range want {
+		if line[key] != value {
+			t.Errorf("%s = %v, want %v", key, line[key], value)
+		}
+	}
+}
+
+func TestLogger_WithTypedFields_allocs(t *testing.T) {
+	logr := newDiscardLogger()
+
+	boxed := testing.AllocsPerRun(100, func() {
+		logr.WithFields(String("s", "v"), Int64("i", 42), Bool("b", true)).Info("typed")
+	})
+	unboxed := testing.AllocsPerRun(100, func() {
+		logr.WithTypedFields(String("s", "v"), Int64("i", 42), Bool("b", true)).Info("typed")
+	})
+	if unboxed >= boxed {
+		t.Errorf("allocations of WithTypedFields = %v, want less than the %v of WithFields", unboxed, boxed)
+	}
+
+	one := testing.AllocsPerRun(100, func() {
+		logr.WithTypedFields(String("s", "v")).Info("typed")
+	})
+	six := testing.AllocsPerRun(100, func() {
+		logr.WithTypedFields(String("s", "v"), Int64("i", 42), Bool("b", true),
+			Duration("d", time.Second), String("t", "v"), Int64("j", 1)).Info("typed")
+	})
+	if six != one {
+		t.Errorf("allocations of WithTypedFields with six fields = %v, want the %v of one field", six, one)
+	}
+}
@@ -2,6 +2,7 @@ package flags
 
 import (
 	"strings"
+	"time"
 
 	"github.com/joematpal/go-logger"
 	cli "github.com/urfave/cli/v2"
@@ -12,6 +13,23 @@ var (
 	LogLevel      = "log-level"
 	LogStacktrace = "log-stacktrace"
 	LogEncoding   = "log-encoding"
+
+	LogLevelSignals = "log-level-signals"
+
+	LogFile           = "log-file"
+	LogFileMaxSize    = "log-file-max-size"
+	LogFileMaxAge     = "log-file-max-age"
+	LogFileMaxBackups = "log-file-max-backups"
+	LogFileCompress   = "log-file-compress"
+	LogFileLocalTime  = "log-file-local-time"
+
+	LogSamplingInitial    = "log-sampling-initial"
+	LogSamplingThereafter = "log-sampling-thereafter"
+	LogSamplingTick       = "log-sampling-tick"
+
+	LogRateLimit                = "log-rate-limit"
+	LogRateLimitBurst           = "log-rate-limit-burst"
+	LogRateLimitSummaryInterval = "log-rate-limit-summary-interval"
 )
 
 var LogFlags = []cli.Flag{
@@ -36,10 +54,80 @@ var LogFlags = []cli.Flag{
 	},
 	&cli.GenericFlag{
 		Name:    LogEncoding,
-		Usage:   "values: json, encoding",
+		Usage:   "values: json, console, logfmt",
 		Value:   logger.NewLogEncodingEnum(),
 		EnvVars: flagNamesToEnv(LogEncoding),
 	},
+	&cli.BoolFlag{
+		Name:    LogLevelSignals,
+		Usage:   "SIGUSR1 makes the log level more verbose and SIGUSR2 less verbose",
+		EnvVars: flagNamesToEnv(LogLevelSignals),
+	},
+	&cli.StringSliceFlag{
+		Name:    LogFile,
+		Usage:   "writes the logs to the file; can be repeated",
+		EnvVars: flagNamesToEnv(LogFile),
+	},
+	&cli.IntFlag{
+		Name:    LogFileMaxSize,
+		Usage:   "rotates the log file once it grows over this many megabytes; 0 disables it",
+		EnvVars: flagNamesToEnv(LogFileMaxSize),
+	},
+	&cli.DurationFlag{
+		Name:    LogFileMaxAge,
+		Usage:   "removes rotated log files older than this; 0 keeps them",
+		EnvVars: flagNamesToEnv(LogFileMaxAge),
+	},
+	&cli.IntFlag{
+		Name:    LogFileMaxBackups,
+		Usage:   "maximum number of rotated log files to keep; 0 keeps them all",
+		EnvVars: flagNamesToEnv(LogFileMaxBackups),
+	},
+	&cli.BoolFlag{
+		Name:    LogFileCompress,
+		Usage:   "gzips the rotated log files",
+		EnvVars: flagNamesToEnv(LogFileCompress),
+	},
+	&cli.BoolFlag{
+		Name:    LogFileLocalTime,
+		Usage:   "uses the local time instead of UTC in the rotated log file names",
+		EnvVars: flagNamesToEnv(LogFileLocalTime),
+	},
+	&cli.IntFlag{
+		Name:    LogSamplingInitial,
+		Value:   100,
+		Usage:   "writes this many entries with the same level and message every tick before sampling; 0 disables the sampling",
+		EnvVars: flagNamesToEnv(LogSamplingInitial),
+	},
+	&cli.IntFlag{
+		Name:    LogSamplingThereafter,
+		Value:   100,
+		Usage:   "writes every nth entry with the same level and message after the initial ones in a tick",
+		EnvVars: flagNamesToEnv(LogSamplingThereafter),
+	},
+	&cli.DurationFlag{
+		Name:    LogSamplingTick,
+		Value:   time.Second,
+		Usage:   "how often the sampling counts are reset",
+		EnvVars: flagNamesToEnv(LogSamplingTick),
+	},
+	&cli.Float64Flag{
+		Name:    LogRateLimit,
+		Usage:   "entries per second allowed for each level and message template; 0 disables the limit",
+		EnvVars: flagNamesToEnv(LogRateLimit),
+	},
+	&cli.IntFlag{
+		Name:    LogRateLimitBurst,
+		Value:   10,
+		Usage:   "entries allowed at once for each level and message template over the rate limit",
+		EnvVars: flagNamesToEnv(LogRateLimitBurst),
+	},
+	&cli.DurationFlag{
+		Name:    LogRateLimitSummaryInterval,
+		Value:   10 * time.Second,
+		Usage:   "how often the number of entries suppressed by the rate limit is logged",
+		EnvVars: flagNamesToEnv(LogRateLimitSummaryInterval),
+	},
 }
 
 func flagNamesToEnv(names ...string) []string {
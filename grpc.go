@@ -3,24 +3,39 @@ package logger
 import (
 	"context"
 	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/rs/xid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
 	ErrNoIncomingMetadata = errors.New("no incoming metadata")
 	ErrNoCorrelationID    = errors.New("no correlation id")
-	newID                 = func() string {
-		return xid.New().String()
-	}
-	Key_CorrelationID = "correlation_id"
+	// ErrInvalidCorrelationID is returned for ids that are not a ValidCorrelationID; they are replaced like missing ones
+	ErrInvalidCorrelationID = errors.New("invalid correlation id")
+	newID                   = NewXID
+	Key_CorrelationID       = "correlation_id"
 )
 
 type wrappedStream struct {
+	// the counters are first to keep them 64-bit aligned for atomic
+	sent, received         int64
+	bytesSent, bytesRecved int64
+
 	ctx context.Context
 	grpc.ServerStream
+
+	// logr is set when the messages of the stream are counted and logged
+	logr       CorrelationLogger
+	config     *interceptorConfig
+	logPayload bool
 }
 
 func (ws *wrappedStream) Context() context.Context {
@@ -28,23 +43,91 @@ func (ws *wrappedStream) Context() context.Context {
 }
 
 func newWrappedStream(ctx context.Context, s grpc.ServerStream) grpc.ServerStream {
-	return &wrappedStream{ctx, s}
+	return &wrappedStream{ctx: ctx, ServerStream: s}
+}
+
+func (ws *wrappedStream) SendMsg(m interface{}) error {
+	err := ws.ServerStream.SendMsg(m)
+	if err != nil || ws.logr == nil {
+		return err
+	}
+
+	size := messageSize(m)
+	count := atomic.AddInt64(&ws.sent, 1)
+	atomic.AddInt64(&ws.bytesSent, int64(size))
+	ws.logMessage("sent message", count, size, m)
+	return nil
+}
+
+func (ws *wrappedStream) RecvMsg(m interface{}) error {
+	err := ws.ServerStream.RecvMsg(m)
+	if err != nil || ws.logr == nil {
+		return err
+	}
+
+	size := messageSize(m)
+	count := atomic.AddInt64(&ws.received, 1)
+	atomic.AddInt64(&ws.bytesRecved, int64(size))
+	ws.logMessage("received message", count, size, m)
+	return nil
+}
+
+func (ws *wrappedStream) logMessage(msg string, count int64, size int, m interface{}) {
+	if !ws.config.messageEvents {
+		return
+	}
+	fields := []Field{
+		KV{"grpc.msg_index", count},
+		KV{"grpc.msg_size", size},
+	}
+	if ws.logPayload {
+		fields = append(fields, KV{"grpc.msg", ws.config.redactor.redact(m)})
+	}
+	ws.logr.WithFields(fields...).Debug(msg)
 }
 
-func LoggingStreamServerInterceptor(logger CorrelationLogger) grpc.StreamServerInterceptor {
+// summaryFields returns the message totals of the stream
+func (ws *wrappedStream) summaryFields() []Field {
+	return []Field{
+		KV{"grpc.msgs_sent", atomic.LoadInt64(&ws.sent)},
+		KV{"grpc.msgs_received", atomic.LoadInt64(&ws.received)},
+		KV{"grpc.bytes_sent", atomic.LoadInt64(&ws.bytesSent)},
+		KV{"grpc.bytes_received", atomic.LoadInt64(&ws.bytesRecved)},
+	}
+}
+
+// messageSize returns the encoded size of protobuf messages and 0 for anything else
+func messageSize(m interface{}) int {
+	if pm, ok := m.(proto.Message); ok {
+		return proto.Size(pm)
+	}
+	return 0
+}
+
+func LoggingStreamServerInterceptor(logger CorrelationLogger, opts ...InterceptorOption) grpc.StreamServerInterceptor {
+	config := newInterceptorConfig(opts...)
+
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		cID, err := GetCorrelationIDFromMetadata(ss.Context())
+		start := time.Now()
+		ctx := ss.Context()
+		cID, err := GetCorrelationIDFromMetadata(ctx)
 		if err != nil {
-			if !errors.Is(err, ErrNoCorrelationID) {
+			if !errors.Is(err, ErrNoCorrelationID) && !errors.Is(err, ErrInvalidCorrelationID) {
 				return err
 			}
-			logger.Debug("no correlation id")
-			md, _ := metadata.FromIncomingContext(ss.Context())
-			md = metadata.Join(md, metadata.New(map[string]string{Key_CorrelationID: cID}))
-			ss = newWrappedStream(metadata.NewIncomingContext(ss.Context(), md), ss)
+			logger.Debug(err.Error())
+			cID = newCorrelationID(ctx, logger)
+			ctx = withIncomingCorrelationID(ctx, cID)
 		}
 
 		logr := logger.WithCorrelationID(cID)
+		ws := &wrappedStream{
+			ctx:          IntoContext(ctx, logr),
+			ServerStream: ss,
+			logr:         logr,
+			config:       config,
+			logPayload:   config.logPayload(info.FullMethod),
+		}
 
 		if l, ok := logr.(FieldLogger); ok {
 			l.
@@ -53,48 +136,91 @@ func LoggingStreamServerInterceptor(logger CorrelationLogger) grpc.StreamServerI
 		} else {
 			logr.Infof("stream_server_interceptor=%s", info.FullMethod)
 		}
-		if err := handler(srv, ss); err != nil {
-			logr.Errorf("stream_server_interceptor=%v", err)
-		}
-		return nil
+
+		err = handler(srv, ws)
+
+		fields := serverCallFields(ws.Context(), start, err)
+		fields = append(fields, ws.summaryFields()...)
+		fields = append(fields, KV{"stream_server_interceptor", info.FullMethod})
+		logAtLevel(logr.WithFields(fields...), config.codeToLevel(status.Code(err)), "finished streaming call")
+		return err
 	}
 }
 
-func LoggingUnaryServerInterceptor(logger CorrelationLogger) grpc.UnaryServerInterceptor {
+func LoggingUnaryServerInterceptor(logger CorrelationLogger, opts ...InterceptorOption) grpc.UnaryServerInterceptor {
+	config := newInterceptorConfig(opts...)
+
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
 		cID, err := GetCorrelationIDFromMetadata(ctx)
 
 		if err != nil {
-			if !errors.Is(err, ErrNoCorrelationID) {
+			if !errors.Is(err, ErrNoCorrelationID) && !errors.Is(err, ErrInvalidCorrelationID) {
 				return nil, err
 			}
-			md, _ := metadata.FromIncomingContext(ctx)
-			md = metadata.Join(md, metadata.New(map[string]string{Key_CorrelationID: cID}))
-			logger.Debug("no correlation id")
-			ctx = metadata.NewIncomingContext(ctx, md)
+			logger.Debug(err.Error())
+			cID = newCorrelationID(ctx, logger)
+			ctx = withIncomingCorrelationID(ctx, cID)
 		}
 		logr := logger.WithCorrelationID(cID)
+		ctx = IntoContext(ctx, logr)
+
+		logPayload := config.logPayload(info.FullMethod)
 
 		if l, ok := logr.(FieldLogger); ok {
-			l.WithFields(
-				KV{"request", req},
-				KV{"unary_server_interceptor", info.FullMethod},
-			).Info("")
+			fields := []Field{}
+			if logPayload {
+				fields = append(fields, KV{"request", config.redactor.redact(req)})
+			}
+			fields = append(fields, KV{"unary_server_interceptor", info.FullMethod})
+			l.WithFields(fields...).Info("")
 		} else {
-			logr.Infof("unary_server_interceptor=%s request=%+v", info.FullMethod, req)
+			logr.Infof("unary_server_interceptor=%s", info.FullMethod)
 		}
 
 		resp, err := handler(ctx, req)
-		if err != nil {
-			logger.Errorf("unary_server_interceptor=%v", err)
+
+		fields := serverCallFields(ctx, start, err)
+		if err == nil {
+			fields = append(fields, KV{"grpc.response_size", messageSize(resp)})
+			if logPayload {
+				fields = append(fields, KV{"response", config.redactor.redact(resp)})
+			}
 		}
+		fields = append(fields, KV{"unary_server_interceptor", info.FullMethod})
+		logAtLevel(logr.WithFields(fields...), config.codeToLevel(status.Code(err)), "finished unary call")
 
 		return resp, err
 	}
 }
 
+// serverCallFields returns the fields of the log line written when a call finishes
+func serverCallFields(ctx context.Context, start time.Time, err error) []Field {
+	fields := []Field{
+		KV{"grpc.code", status.Code(err).String()},
+		KV{"grpc.duration_ms", durationToMilliseconds(time.Since(start))},
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, KV{"peer.address", p.Addr.String()})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		fields = append(fields, KV{"grpc.deadline_remaining_ms", durationToMilliseconds(time.Until(deadline))})
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ua := md.Get("user-agent"); len(ua) > 0 {
+			fields = append(fields, KV{"user_agent", ua[0]})
+		}
+	}
+	fields = append(fields, traceFields(spanContextFromContext(ctx))...)
+	if err != nil {
+		fields = append(fields, KV{"error", err.Error()})
+	}
+	return fields
+}
+
 // GetCorrelationIDFromMetadata will get the correlation_id from grpc context
-// makes a new one if not present, but will still return an error
+// returns the first value that is a ValidCorrelationID
+// makes a new one if none is, but will still return an error
 func GetCorrelationIDFromMetadata(ctx context.Context) (string, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
@@ -103,12 +229,123 @@ func GetCorrelationIDFromMetadata(ctx context.Context) (string, error) {
 
 	values := md.Get(Key_CorrelationID)
 
+	err := ErrNoCorrelationID
 	for _, val := range values {
+		if val == "" {
+			continue
+		}
+		if !ValidCorrelationID(val) {
+			err = ErrInvalidCorrelationID
+			continue
+		}
+		return val, nil
+	}
+
+	return newID(), err
+}
+
+// withIncomingCorrelationID replaces the correlation id of the incoming metadata
+func withIncomingCorrelationID(ctx context.Context, cID string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	md.Set(Key_CorrelationID, cID)
+	return metadata.NewIncomingContext(ctx, md)
+}
 
-		if val != "" {
-			return val, nil
+// outgoingCorrelationID returns a context whose outgoing metadata carries the correlation id.
+// The id is taken from the outgoing metadata, then from the context (see correlationIDFromContext)
+// and a new one is generated when none is found.
+func outgoingCorrelationID(ctx context.Context, logr CorrelationLogger) (context.Context, string) {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		for _, val := range md.Get(Key_CorrelationID) {
+			if val != "" {
+				return ctx, val
+			}
 		}
 	}
 
-	return newID(), ErrNoCorrelationID
+	cID := correlationIDFromContext(ctx)
+	if cID == "" {
+		cID = newCorrelationID(ctx, logr)
+	}
+	return metadata.AppendToOutgoingContext(ctx, Key_CorrelationID, cID), cID
+}
+
+// logClientCall logs the end of an outgoing call
+func logClientCall(logr CorrelationLogger, config *interceptorConfig, key, method string, start time.Time, err error) {
+	fields := []Field{
+		KV{key, method},
+		KV{"grpc.code", status.Code(err).String()},
+		KV{"grpc.duration_ms", durationToMilliseconds(time.Since(start))},
+	}
+	if err != nil {
+		fields = append(fields, KV{"error", err.Error()})
+	}
+	logAtLevel(logr.WithFields(fields...), config.codeToLevel(status.Code(err)), "")
+}
+
+func durationToMilliseconds(d time.Duration) float32 {
+	return float32(d.Nanoseconds()/1000) / 1000
+}
+
+// LoggingUnaryClientInterceptor forwards the correlation id in the outgoing metadata and logs the call
+func LoggingUnaryClientInterceptor(logger CorrelationLogger, opts ...InterceptorOption) grpc.UnaryClientInterceptor {
+	config := newInterceptorConfig(opts...)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cID := outgoingCorrelationID(ctx, logger)
+		logr := logger.WithCorrelationID(cID)
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		logClientCall(logr, config, "unary_client_interceptor", method, start, err)
+		return err
+	}
+}
+
+type wrappedClientStream struct {
+	grpc.ClientStream
+	desc   *grpc.StreamDesc
+	once   sync.Once
+	finish func(err error)
+}
+
+func (ws *wrappedClientStream) RecvMsg(m interface{}) error {
+	err := ws.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		ws.once.Do(func() { ws.finish(nil) })
+	case err != nil:
+		ws.once.Do(func() { ws.finish(err) })
+	case !ws.desc.ServerStreams:
+		// the server sends a single response so the stream is done
+		ws.once.Do(func() { ws.finish(nil) })
+	}
+	return err
+}
+
+// LoggingStreamClientInterceptor forwards the correlation id in the outgoing metadata
+// and logs the stream once it is finished
+func LoggingStreamClientInterceptor(logger CorrelationLogger, opts ...InterceptorOption) grpc.StreamClientInterceptor {
+	config := newInterceptorConfig(opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, cID := outgoingCorrelationID(ctx, logger)
+		logr := logger.WithCorrelationID(cID)
+
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			logClientCall(logr, config, "stream_client_interceptor", method, start, err)
+			return nil, err
+		}
+
+		return &wrappedClientStream{
+			ClientStream: cs,
+			desc:         desc,
+			finish: func(err error) {
+				logClientCall(logr, config, "stream_client_interceptor", method, start, err)
+			},
+		}, nil
+	}
 }
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"google.golang.org/grpc/codes"
+)
+
+// CodeToLevel picks the level of the log line written when a call finishes
+type CodeToLevel func(code codes.Code) LogLevel
+
+// DefaultCodeToLevel logs client errors at info, conditions worth a look at warn
+// and server failures at error
+func DefaultCodeToLevel(code codes.Code) LogLevel {
+	switch code {
+	case codes.OK,
+		codes.Canceled,
+		codes.InvalidArgument,
+		codes.NotFound,
+		codes.AlreadyExists,
+		codes.Unauthenticated:
+		return InfoLevel
+	case codes.DeadlineExceeded,
+		codes.PermissionDenied,
+		codes.ResourceExhausted,
+		codes.FailedPrecondition,
+		codes.Aborted,
+		codes.OutOfRange,
+		codes.Unavailable:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+type interceptorConfig struct {
+	codeToLevel    CodeToLevel
+	messageEvents  bool
+	payloads       bool
+	payloadDecider PayloadDecider
+	redactedFields []string
+	maxPayloadSize int
+	redactor       *payloadRedactor
+}
+
+// logPayload reports if the bodies of the method are logged, the same way for requests and responses
+// of unary and streaming calls
+func (c *interceptorConfig) logPayload(fullMethod string) bool {
+	return c.payloads && (c.payloadDecider == nil || c.payloadDecider(fullMethod))
+}
+
+// InterceptorOption configures the logging interceptors
+type InterceptorOption interface {
+	applyInterceptorOption(*interceptorConfig)
+}
+
+type applyInterceptorOptionFunc func(*interceptorConfig)
+
+func (f applyInterceptorOptionFunc) applyInterceptorOption(c *interceptorConfig) {
+	f(c)
+}
+
+func newInterceptorConfig(opts ...InterceptorOption) *interceptorConfig {
+	config := &interceptorConfig{
+		codeToLevel: DefaultCodeToLevel,
+	}
+	for _, opt := range opts {
+		opt.applyInterceptorOption(config)
+	}
+	config.redactor = newPayloadRedactor(config.redactedFields, config.maxPayloadSize)
+	return config
+}
+
+// WithCodeToLevel replaces DefaultCodeToLevel
+func WithCodeToLevel(codeToLevel CodeToLevel) InterceptorOption {
+	return applyInterceptorOptionFunc(func(c *interceptorConfig) {
+		if codeToLevel != nil {
+			c.codeToLevel = codeToLevel
+		}
+	})
+}
+
+// WithMessageEvents logs every message sent and received on a stream at the debug level
+// with its index and size
+func WithMessageEvents(enabled bool) InterceptorOption {
+	return applyInterceptorOptionFunc(func(c *interceptorConfig) {
+		c.messageEvents = enabled
+	})
+}
+
+// WithPayloads adds the messages themselves to the message events
+// and the request and response to the log lines of a unary call
+func WithPayloads(enabled bool) InterceptorOption {
+	return applyInterceptorOptionFunc(func(c *interceptorConfig) {
+		c.payloads = enabled
+	})
+}
+
+// WithPayloadDecider only logs the request and response bodies of the methods it returns true for
+// when WithPayloads is enabled. Every method is logged by default.
+func WithPayloadDecider(decider PayloadDecider) InterceptorOption {
+	return applyInterceptorOptionFunc(func(c *interceptorConfig) {
+		c.payloadDecider = decider
+	})
+}
+
+// WithRedactedFields replaces the values at the field paths in the logged bodies.
+// A path with a single name ("password") matches the field at any depth,
+// a dotted path ("user.token") matches from the root and "*" matches any field.
+// Protobuf fields marked with the debug_redact option are always redacted.
+func WithRedactedFields(paths ...string) InterceptorOption {
+	return applyInterceptorOptionFunc(func(c *interceptorConfig) {
+		c.redactedFields = append(c.redactedFields, paths...)
+	})
+}
+
+// WithMaxPayloadSize truncates the logged bodies to size bytes of json; 0 does not truncate
+func WithMaxPayloadSize(size int) InterceptorOption {
+	return applyInterceptorOptionFunc(func(c *interceptorConfig) {
+		c.maxPayloadSize = size
+	})
+}
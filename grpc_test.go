@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"testing"
 	"time"
 
@@ -13,7 +14,11 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestGetCorrelationIDFromMetadata(t *testing.T) {
@@ -47,9 +52,29 @@ func TestGetCorrelationIDFromMetadata(t *testing.T) {
 		{
 			name: "should pass; with correlation_id",
 			args: args{
-				ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("correlation_id", "with corr id")),
+				ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("correlation_id", "with_corr_id")),
 			},
-			want: "with corr id",
+			want: "with_corr_id",
+		},
+		{
+			name: "should pass; replaces an invalid correlation_id",
+			args: args{
+				ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("correlation_id", "with corr id\n")),
+			},
+			need:    "replaced id",
+			want:    "replaced id",
+			wantErr: true,
+		},
+		{
+			name: "should pass; skips an invalid correlation_id for a valid one",
+			args: args{
+				ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+					"correlation_id", "with corr id\n",
+					"correlation_id", "",
+					"correlation_id", "with_corr_id",
+				)),
+			},
+			want: "with_corr_id",
 		},
 	}
 	for _, tt := range tests {
@@ -103,6 +128,7 @@ func TestLoggingStreamServerInterceptor(t *testing.T) {
 			wants: []testLogMsg{
 				{Level: "debug", Msg: "no correlation id"},
 				{Level: "info", Msg: "", CorrelationId: "new_test_cor_id", StreamServerInterceptor: "test/test/test"},
+				{Level: "info", Msg: "finished streaming call", CorrelationId: "new_test_cor_id", StreamServerInterceptor: "test/test/test"},
 			},
 		},
 		{
@@ -112,6 +138,7 @@ func TestLoggingStreamServerInterceptor(t *testing.T) {
 			},
 			wants: []testLogMsg{
 				{Level: "info", Msg: "", CorrelationId: "test_with_cor_id", StreamServerInterceptor: "test/test/test"},
+				{Level: "info", Msg: "finished streaming call", CorrelationId: "test_with_cor_id", StreamServerInterceptor: "test/test/test"},
 			},
 		},
 	}
@@ -235,6 +262,7 @@ func TestLoggingUnaryServerInterceptor(t *testing.T) {
 			wants: []testLogMsg{
 				{Level: "debug", Msg: "no correlation id"},
 				{Level: "info", Msg: "", CorrelationId: "new_test_cor_id", UnaryServerInterceptor: "test/test/test"},
+				{Level: "info", Msg: "finished unary call", CorrelationId: "new_test_cor_id", UnaryServerInterceptor: "test/test/test"},
 			},
 		},
 		{
@@ -244,6 +272,7 @@ func TestLoggingUnaryServerInterceptor(t *testing.T) {
 			},
 			wants: []testLogMsg{
 				{Level: "info", Msg: "", CorrelationId: "test_with_cor_id", UnaryServerInterceptor: "test/test/test"},
+				{Level: "info", Msg: "finished unary call", CorrelationId: "test_with_cor_id", UnaryServerInterceptor: "test/test/test"},
 			},
 		},
 	}
@@ -308,3 +337,315 @@ func TestLoggingUnaryServerInterceptor(t *testing.T) {
 		})
 	}
 }
+
+func TestLoggingUnaryClientInterceptor(t *testing.T) {
+	newID = func() string {
+		return "new_test_cor_id"
+	}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		invokerErr error
+		want       string
+		wantLevel  string
+		wantCode   string
+	}{
+		{
+			name:      "should pass; generates a correlation id",
+			ctx:       context.Background(),
+			want:      "new_test_cor_id",
+			wantLevel: "info",
+			wantCode:  "OK",
+		},
+		{
+			name:      "should pass; forwards the incoming correlation id",
+			ctx:       metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_CorrelationID, "incoming_cor_id")),
+			want:      "incoming_cor_id",
+			wantLevel: "info",
+			wantCode:  "OK",
+		},
+		{
+			name:      "should pass; keeps the outgoing correlation id",
+			ctx:       metadata.AppendToOutgoingContext(context.Background(), Key_CorrelationID, "outgoing_cor_id"),
+			want:      "outgoing_cor_id",
+			wantLevel: "info",
+			wantCode:  "OK",
+		},
+		{
+			name:       "should pass; logs the error code",
+			ctx:        IntoContext(context.Background(), newNopLogger().WithCorrelationID("ctx_cor_id")),
+			invokerErr: status.Error(codes.Internal, "internal"),
+			want:       "ctx_cor_id",
+			wantLevel:  "error",
+			wantCode:   "Internal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &syncBuffer{}
+			logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				got = md.Get(Key_CorrelationID)
+				return tt.invokerErr
+			}
+
+			middleware := LoggingUnaryClientInterceptor(logr)
+			if err := middleware(tt.ctx, "test/test/test", nil, nil, nil, invoker); err != tt.invokerErr {
+				t.Fatalf("LoggingUnaryClientInterceptor() error = %v, want %v", err, tt.invokerErr)
+			}
+
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("outgoing correlation id = %v, want %v", got, tt.want)
+			}
+
+			line := buf.lines(t, 1)[0]
+			if line["correlation_id"] != tt.want || line["level"] != tt.wantLevel || line["grpc.code"] != tt.wantCode || line["unary_client_interceptor"] != "test/test/test" {
+				t.Errorf("log line = %v", line)
+			}
+			if _, ok := line["grpc.duration_ms"]; !ok {
+				t.Errorf("log line has no duration: %v", line)
+			}
+		})
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	recv []error
+}
+
+func (cs *clientStream) RecvMsg(m interface{}) error {
+	err := cs.recv[0]
+	cs.recv = cs.recv[1:]
+	return err
+}
+
+func TestLoggingStreamClientInterceptor(t *testing.T) {
+	buf := &syncBuffer{}
+	logr, err := New(WithEncoding(jsonEncoder), WithWriters(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		got = md.Get(Key_CorrelationID)
+		return &clientStream{recv: []error{nil, nil, io.EOF}}, nil
+	}
+
+	middleware := LoggingStreamClientInterceptor(logr)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_CorrelationID, "stream_cor_id"))
+	cs, err := middleware(ctx, &grpc.StreamDesc{ServerStreams: true}, nil, "test/test/test", streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if err := cs.RecvMsg(nil); err != nil {
+			break
+		}
+	}
+
+	if len(got) != 1 || got[0] != "stream_cor_id" {
+		t.Errorf("outgoing correlation id = %v, want %v", got, "stream_cor_id")
+	}
+
+	line := buf.lines(t, 1)[0]
+	if line["correlation_id"] != "stream_cor_id" || line["grpc.code"] != "OK" || line["stream_client_interceptor"] != "test/test/test" {
+		t.Errorf("log line = %v", line)
+	}
+}
+
+func TestLoggingUnaryServerInterceptor_completion(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []InterceptorOption
+		handlerErr error
+		wantLevel  string
+		wantCode   string
+	}{
+		{
+			name:      "should pass; ok is info",
+			wantLevel: "info",
+			wantCode:  "OK",
+		},
+		{
+			name:       "should pass; unavailable is warn",
+			handlerErr: status.Error(codes.Unavailable, "unavailable"),
+			wantLevel:  "warn",
+			wantCode:   "Unavailable",
+		},
+		{
+			name:       "should pass; internal is error",
+			handlerErr: status.Error(codes.Internal, "internal"),
+			wantLevel:  "error",
+			wantCode:   "Internal",
+		},
+		{
+			name: "should pass; with a code to level mapping",
+			opts: []InterceptorOption{
+				WithCodeToLevel(func(code codes.Code) LogLevel {
+					return DebugLevel
+				}),
+			},
+			handlerErr: status.Error(codes.Internal, "internal"),
+			wantLevel:  "debug",
+			wantCode:   "Internal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &syncBuffer{}
+			logr, err := New(WithLevel(debug), WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_CorrelationID, "cor_id", "user-agent", "test-agent"))
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+			ctx, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, tt.handlerErr
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: "test/test/test"}
+
+			if _, err := LoggingUnaryServerInterceptor(logr, tt.opts...)(ctx, nil, info, handler); err != tt.handlerErr {
+				t.Fatalf("LoggingUnaryServerInterceptor() error = %v, want %v", err, tt.handlerErr)
+			}
+
+			got := buf.lines(t, 2)[1]
+			if got["level"] != tt.wantLevel || got["grpc.code"] != tt.wantCode {
+				t.Errorf("level = %v, code = %v; want %v, %v", got["level"], got["grpc.code"], tt.wantLevel, tt.wantCode)
+			}
+			if got["peer.address"] != "10.0.0.1:5000" || got["user_agent"] != "test-agent" {
+				t.Errorf("log line = %v", got)
+			}
+			for _, key := range []string{"grpc.duration_ms", "grpc.deadline_remaining_ms"} {
+				if _, ok := got[key]; !ok {
+					t.Errorf("log line has no %s: %v", key, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLoggingStreamServerInterceptor_messages(t *testing.T) {
+	buf := &syncBuffer{}
+	logr, err := New(WithLevel(debug), WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handlerErr := status.Error(codes.Internal, "internal")
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		if err := ss.RecvMsg(wrapperspb.String("abc")); err != nil {
+			return err
+		}
+		for _, msg := range []string{"one", "two"} {
+			if err := ss.SendMsg(wrapperspb.String(msg)); err != nil {
+				return err
+			}
+		}
+		return handlerErr
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_CorrelationID, "cor_id"))
+	middleware := LoggingStreamServerInterceptor(logr, WithMessageEvents(true), WithPayloads(true))
+	if err := middleware(nil, newServerStream(ctx), &grpc.StreamServerInfo{FullMethod: "test/test/test"}, handler); err != handlerErr {
+		t.Fatalf("LoggingStreamServerInterceptor() error = %v, want %v", err, handlerErr)
+	}
+
+	lines := buf.lines(t, 5)
+	wants := []struct {
+		msg   string
+		index float64
+	}{
+		{"received message", 1},
+		{"sent message", 1},
+		{"sent message", 2},
+	}
+	for i, want := range wants {
+		got := lines[i+1]
+		if got["msg"] != want.msg || got["grpc.msg_index"] != want.index || got["level"] != "debug" {
+			t.Errorf("lines[%d] = %v, want %s %v", i+1, got, want.msg, want.index)
+		}
+		if got["grpc.msg_size"] != float64(5) || got["grpc.msg"] == nil {
+			t.Errorf("lines[%d] has no size or payload: %v", i+1, got)
+		}
+	}
+
+	summary := lines[4]
+	if summary["msg"] != "finished streaming call" || summary["grpc.code"] != "Internal" || summary["level"] != "error" {
+		t.Errorf("summary = %v", summary)
+	}
+	if summary["grpc.msgs_sent"] != float64(2) || summary["grpc.msgs_received"] != float64(1) || summary["grpc.bytes_sent"] != float64(10) {
+		t.Errorf("summary counts = %v", summary)
+	}
+}
+
+func TestLoggingUnaryServerInterceptor_payloads(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []InterceptorOption
+		wantRequest  interface{}
+		wantResponse interface{}
+	}{
+		{
+			name: "should pass; bodies are not logged without payloads",
+			opts: []InterceptorOption{WithRedactedFields("token")},
+		},
+		{
+			name: "should pass; redacts the request and logs the response",
+			opts: []InterceptorOption{
+				WithRedactedFields("token"),
+				WithPayloads(true),
+			},
+			wantRequest:  map[string]interface{}{"user": "joe", "token": "[REDACTED]"},
+			wantResponse: map[string]interface{}{"ok": true},
+		},
+		{
+			name: "should pass; method is not logged",
+			opts: []InterceptorOption{
+				WithPayloadDecider(MatchMethods("/test.Other/*")),
+				WithPayloads(true),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &syncBuffer{}
+			logr, err := New(WithEncoding(jsonEncoder), WithWriters(buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_CorrelationID, "cor_id"))
+			req := map[string]interface{}{"user": "joe", "token": "secret"}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return map[string]interface{}{"ok": true}, nil
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: "/test.Users/Get"}
+
+			if _, err := LoggingUnaryServerInterceptor(logr, tt.opts...)(ctx, req, info, handler); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := buf.lines(t, 2)
+			if got := lines[0]["request"]; !cmp.Equal(got, tt.wantRequest) {
+				t.Errorf("request = %v, want %v", got, tt.wantRequest)
+			}
+			if got := lines[1]["response"]; !cmp.Equal(got, tt.wantResponse) {
+				t.Errorf("response = %v, want %v", got, tt.wantResponse)
+			}
+		})
+	}
+}
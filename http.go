@@ -0,0 +1,407 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultCorrelationHeader is the http header the correlation id is read from and written to
+const DefaultCorrelationHeader = "X-Correlation-ID"
+
+// StatusToLevel picks the level of the access log line from the http status code
+type StatusToLevel func(status int) LogLevel
+
+// DefaultStatusToLevel logs server errors at error and everything else at info
+func DefaultStatusToLevel(status int) LogLevel {
+	if status >= http.StatusInternalServerError {
+		return ErrorLevel
+	}
+	return InfoLevel
+}
+
+// DefaultRedactedQueryParams are the query parameters replaced in logged urls
+var DefaultRedactedQueryParams = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"client_secret",
+	"code",
+	"key",
+	"password",
+	"secret",
+	"sig",
+	"signature",
+	"token",
+}
+
+type httpConfig struct {
+	header        string
+	route         func(r *http.Request) string
+	statusToLevel StatusToLevel
+	// redactedQuery holds the lower case names of the query parameters to redact
+	redactedQuery map[string]struct{}
+	retries       int
+	retryBackoff  time.Duration
+}
+
+// HTTPOption configures the http middleware and round tripper
+type HTTPOption interface {
+	applyHTTPOption(*httpConfig)
+}
+
+type applyHTTPOptionFunc func(*httpConfig)
+
+func (f applyHTTPOptionFunc) applyHTTPOption(c *httpConfig) {
+	f(c)
+}
+
+func newHTTPConfig(opts ...HTTPOption) *httpConfig {
+	config := &httpConfig{
+		header: DefaultCorrelationHeader,
+		route: func(r *http.Request) string {
+			return r.URL.Path
+		},
+		statusToLevel: DefaultStatusToLevel,
+		redactedQuery: map[string]struct{}{},
+	}
+	for _, param := range DefaultRedactedQueryParams {
+		config.redactedQuery[param] = struct{}{}
+	}
+	for _, opt := range opts {
+		opt.applyHTTPOption(config)
+	}
+	return config
+}
+
+// WithCorrelationHeader replaces DefaultCorrelationHeader
+func WithCorrelationHeader(header string) HTTPOption {
+	return applyHTTPOptionFunc(func(c *httpConfig) {
+		if header != "" {
+			c.header = header
+		}
+	})
+}
+
+// WithRoute sets how the route of a request is logged; the url path is used by default.
+// Routers usually expose the matched pattern which keeps the cardinality of the field low.
+func WithRoute(route func(r *http.Request) string) HTTPOption {
+	return applyHTTPOptionFunc(func(c *httpConfig) {
+		if route != nil {
+			c.route = route
+		}
+	})
+}
+
+// WithStatusToLevel replaces DefaultStatusToLevel
+func WithStatusToLevel(statusToLevel StatusToLevel) HTTPOption {
+	return applyHTTPOptionFunc(func(c *httpConfig) {
+		if statusToLevel != nil {
+			c.statusToLevel = statusToLevel
+		}
+	})
+}
+
+// WithRedactedQueryParams adds query parameters, matched case insensitively,
+// whose values are replaced in the urls logged by the round tripper
+func WithRedactedQueryParams(params ...string) HTTPOption {
+	return applyHTTPOptionFunc(func(c *httpConfig) {
+		for _, param := range params {
+			c.redactedQuery[strings.ToLower(param)] = struct{}{}
+		}
+	})
+}
+
+// WithRetries lets the round tripper retry a request up to retries times, waiting backoff
+// times the attempt in between. Only transport errors and 502, 503 and 504 responses are retried,
+// only for idempotent methods or requests with an Idempotency-Key header, as the others could be applied twice,
+// and requests with a body are only retried when it can be read again through GetBody.
+func WithRetries(retries int, backoff time.Duration) HTTPOption {
+	return applyHTTPOptionFunc(func(c *httpConfig) {
+		if retries >= 0 {
+			c.retries = retries
+		}
+		if backoff >= 0 {
+			c.retryBackoff = backoff
+		}
+	})
+}
+
+// responseRecorder keeps the status code and size of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+func (rr *responseRecorder) Flush() {
+	if f, ok := rr.ResponseWriter.(http.Flusher); ok {
+		if rr.status == 0 {
+			rr.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack hands over the connection when the underlying ResponseWriter can, as websocket upgrades need;
+// the request is logged as switching protocols unless a status was written before
+func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && rr.status == 0 {
+		rr.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// ReadFrom keeps the sendfile of the underlying ResponseWriter when it has one
+func (rr *responseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	rf, ok := rr.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		// hides ReadFrom so io.Copy does not call it again
+		return io.Copy(struct{ io.Writer }{rr}, src)
+	}
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rf.ReadFrom(src)
+	rr.bytes += int(n)
+	return n, err
+}
+
+// Push starts an HTTP/2 server push when the underlying ResponseWriter supports it
+func (rr *responseRecorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := rr.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// LoggingHTTPMiddleware reads the correlation id from the request header, or creates one,
+// echoes it in the response, stores the CorrelationLogger and the span context of the
+// traceparent header in the request context and writes an access log line once the request is served
+func LoggingHTTPMiddleware(logger CorrelationLogger, opts ...HTTPOption) func(http.Handler) http.Handler {
+	config := newHTTPConfig(opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx := contextWithTraceparent(r.Context(), r.Header.Get(Key_Traceparent))
+
+			cID := r.Header.Get(config.header)
+			switch {
+			case cID == "":
+				logger.Debug(ErrNoCorrelationID.Error())
+				cID = newCorrelationID(ctx, logger)
+			case !ValidCorrelationID(cID):
+				logger.Debug(ErrInvalidCorrelationID.Error())
+				cID = newCorrelationID(ctx, logger)
+			}
+
+			logr := logger.WithCorrelationID(cID)
+			w.Header().Set(config.header, cID)
+
+			rr := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(rr, r.WithContext(IntoContext(ctx, logr)))
+
+			status := rr.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			fields := []Field{
+				KV{"http.method", r.Method},
+				KV{"http.route", config.route(r)},
+				KV{"http.status_code", status},
+				KV{"http.response_size", rr.bytes},
+				KV{"http.duration_ms", durationToMilliseconds(time.Since(start))},
+				KV{"peer.address", r.RemoteAddr},
+			}
+			if ua := r.UserAgent(); ua != "" {
+				fields = append(fields, KV{"user_agent", ua})
+			}
+			fields = append(fields, traceFields(spanContextFromContext(ctx))...)
+			logAtLevel(logr.WithFields(fields...), config.statusToLevel(status), "finished http request")
+		})
+	}
+}
+
+type loggingRoundTripper struct {
+	logger CorrelationLogger
+	next   http.RoundTripper
+	config *httpConfig
+}
+
+// LoggingRoundTripper wraps next, or http.DefaultTransport when nil, to forward the correlation id
+// of the request context in the correlation header and log every attempt of an outgoing call
+func LoggingRoundTripper(logger CorrelationLogger, next http.RoundTripper, opts ...HTTPOption) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &loggingRoundTripper{
+		logger: logger,
+		next:   next,
+		config: newHTTPConfig(opts...),
+	}
+}
+
+func (rt *loggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	cID := req.Header.Get(rt.config.header)
+	if cID == "" {
+		cID = correlationIDFromContext(ctx)
+	}
+	if cID == "" {
+		cID = newCorrelationID(ctx, rt.logger)
+	}
+	logr := rt.logger.WithCorrelationID(cID)
+	redactedURL := rt.redactURL(req.URL)
+
+	for attempt := 1; ; attempt++ {
+		// a RoundTripper must not modify the request it was given
+		r := req.Clone(ctx)
+		r.Header.Set(rt.config.header, cID)
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+
+		start := time.Now()
+		resp, err := rt.next.RoundTrip(r)
+
+		retry := attempt <= rt.config.retries && rt.retryable(req, resp, err)
+		rt.logCall(logr, req, redactedURL, attempt, start, resp, err, retry)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			// drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if rt.config.retryBackoff > 0 {
+			timer := time.NewTimer(rt.config.retryBackoff * time.Duration(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// retryable reports if the attempt failed in a way worth retrying and the request can be sent again
+func (rt *loggingRoundTripper) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if !idempotent(req) {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil && !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// idempotent reports if sending the request twice does the same as sending it once
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	return ok
+}
+
+func (rt *loggingRoundTripper) logCall(logr CorrelationLogger, req *http.Request, redactedURL string, attempt int, start time.Time, resp *http.Response, err error, retry bool) {
+	fields := []Field{
+		KV{"http.method", req.Method},
+		KV{"http.url", redactedURL},
+		KV{"http.attempt", attempt},
+		KV{"http.duration_ms", durationToMilliseconds(time.Since(start))},
+	}
+
+	level := ErrorLevel
+	if resp != nil {
+		fields = append(fields, KV{"http.status_code", resp.StatusCode})
+		level = rt.config.statusToLevel(resp.StatusCode)
+	}
+	if err != nil {
+		fields = append(fields, KV{"error", err.Error()})
+	}
+	if retry {
+		// the call is not over yet so the failed attempt is only a warning
+		fields = append(fields, KV{"http.retrying", true})
+		level = WarnLevel
+	}
+	logAtLevel(logr.WithFields(fields...), level, "finished http call")
+}
+
+// redactURL returns the url without the password and with the values
+// of the redacted query parameters replaced
+func (rt *loggingRoundTripper) redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	if redacted.RawQuery != "" {
+		parts := strings.Split(redacted.RawQuery, "&")
+		for i, part := range parts {
+			key := part
+			if j := strings.IndexByte(part, '='); j >= 0 {
+				key = part[:j]
+			}
+			name, err := url.QueryUnescape(key)
+			if err != nil {
+				name = key
+			}
+			if _, ok := rt.config.redactedQuery[strings.ToLower(name)]; ok {
+				parts[i] = key + "=" + redactedValue
+			}
+		}
+		redacted.RawQuery = strings.Join(parts, "&")
+	}
+	return redacted.Redacted()
+}
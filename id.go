@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// MaxCorrelationIDLength is the longest inbound correlation id that is trusted
+var MaxCorrelationIDLength = 128
+
+// ValidCorrelationID reports if an inbound correlation id is not empty, at most MaxCorrelationIDLength
+// long and only made of ASCII letters, digits and ".", "_", ":" or "-".
+// Invalid ids are replaced by the interceptors and middleware instead of being logged.
+func ValidCorrelationID(id string) bool {
+	if id == "" || len(id) > MaxCorrelationIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z',
+			c >= 'A' && c <= 'Z',
+			c >= '0' && c <= '9',
+			c == '.', c == '_', c == ':', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewXID returns a 20 character xid, the default correlation id
+func NewXID() string {
+	return xid.New().String()
+}
+
+// NewUUIDv4 returns a random RFC 9562 version 4 uuid
+func NewUUIDv4() string {
+	var b [16]byte
+	randomBytes(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+// NewUUIDv7 returns a RFC 9562 version 7 uuid that sorts by its millisecond timestamp
+func NewUUIDv7() string {
+	var b [16]byte
+	randomBytes(b[6:])
+	putMillis(b[:], time.Now())
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a 26 character ULID that sorts by its millisecond timestamp
+func NewULID() string {
+	var b [16]byte
+	randomBytes(b[6:])
+	putMillis(b[:], time.Now())
+
+	// the 128 bits are written as 26 base32 characters from the last one
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// putMillis writes the unix milliseconds of t into the first 48 bits of b
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails when the system has no entropy source left
+		panic(err)
+	}
+}
+
+func formatUUID(b [16]byte) string {
+	var out [36]byte
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out[:])
+}
+
+// newCorrelationID returns the id used when a call carries no valid correlation id:
+// the trace id when the logger was made WithTraceCorrelationID and ctx has a trace,
+// then the generator of WithIDGenerator and newID otherwise
+func newCorrelationID(ctx context.Context, logr CorrelationLogger) string {
+	if id, ok := traceCorrelationID(ctx, logr); ok {
+		return id
+	}
+	if l, ok := logr.(*logger); ok && l.idGenerator != nil {
+		return l.idGenerator()
+	}
+	return newID()
+}
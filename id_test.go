@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestValidCorrelationID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "should pass; xid", id: "9m4e2mr0ui3e8a215n4g", want: true},
+		{name: "should pass; uuid", id: "0190a5c4-6f1e-7c3a-9b2d-3f4e5a6b7c8d", want: true},
+		{name: "should pass; dotted with colons", id: "svc.a:req_1", want: true},
+		{name: "should pass; max length", id: strings.Repeat("a", MaxCorrelationIDLength), want: true},
+		{name: "should fail; empty", id: ""},
+		{name: "should fail; too long", id: strings.Repeat("a", MaxCorrelationIDLength+1)},
+		{name: "should fail; new line", id: "abc\nlevel=error"},
+		{name: "should fail; space", id: "a b"},
+		{name: "should fail; not ascii", id: "idé"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidCorrelationID(tt.id); got != tt.want {
+				t.Errorf("ValidCorrelationID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIDGenerators(t *testing.T) {
+	tests := []struct {
+		name   string
+		gen    func() string
+		format *regexp.Regexp
+		sorted bool
+	}{
+		{
+			name:   "should pass; xid",
+			gen:    NewXID,
+			format: regexp.MustCompile(`^[0-9a-v]{20}$`),
+		},
+		{
+			name:   "should pass; uuid v4",
+			gen:    NewUUIDv4,
+			format: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		},
+		{
+			name:   "should pass; uuid v7",
+			gen:    NewUUIDv7,
+			format: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+			sorted: true,
+		},
+		{
+			name:   "should pass; ulid",
+			gen:    NewULID,
+			format: regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`),
+			sorted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := tt.gen()
+			time.Sleep(2 * time.Millisecond)
+			second := tt.gen()
+
+			for _, id := range []string{first, second} {
+				if !tt.format.MatchString(id) {
+					t.Errorf("id %q does not match %v", id, tt.format)
+				}
+				if !ValidCorrelationID(id) {
+					t.Errorf("id %q is not a valid correlation id", id)
+				}
+			}
+			if first == second {
+				t.Errorf("ids are equal: %v", first)
+			}
+			// the time prefix makes later ids sort after earlier ones
+			if tt.sorted && first[:8] > second[:8] {
+				t.Errorf("ids are not sorted: %v > %v", first, second)
+			}
+		})
+	}
+}
+
+func TestNewULID_time(t *testing.T) {
+	before := time.Now().UnixNano() / int64(time.Millisecond)
+	id := NewULID()
+	after := time.Now().UnixNano() / int64(time.Millisecond)
+
+	// the first 10 characters hold the 48 bit milliseconds
+	var ms int64
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockford, c))
+	}
+	if ms < before || ms > after {
+		t.Errorf("NewULID() = %v holds %d, want between %d and %d", id, ms, before, after)
+	}
+}
+
+func TestWithIDGenerator(t *testing.T) {
+	logr, err := New(WithIDGenerator(func() string { return "generated_id" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want string
+	}{
+		{
+			name: "should pass; missing correlation id",
+			md:   metadata.MD{},
+			want: "generated_id",
+		},
+		{
+			name: "should pass; invalid correlation id",
+			md:   metadata.Pairs(Key_CorrelationID, strings.Repeat("x", MaxCorrelationIDLength+1)),
+			want: "generated_id",
+		},
+		{
+			name: "should pass; valid correlation id",
+			md:   metadata.Pairs(Key_CorrelationID, "valid_id"),
+			want: "valid_id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			var gotMD []string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				got = correlationIDFromContext(ctx)
+				md, _ := metadata.FromIncomingContext(ctx)
+				gotMD = md.Get(Key_CorrelationID)
+				return nil, nil
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+			if _, err := LoggingUnaryServerInterceptor(logr)(ctx, nil, info, handler); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("correlation id = %v, want %v", got, tt.want)
+			}
+			if len(gotMD) != 1 || gotMD[0] != tt.want {
+				t.Errorf("metadata correlation id = %v, want [%v]", gotMD, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(WithIDGenerator(nil)); err == nil {
+		t.Error("WithIDGenerator(nil) should fail")
+	}
+}
@@ -1,5 +1,10 @@
 package logger
 
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
 type KV struct {
 	key   string
 	value interface{}
@@ -22,3 +27,38 @@ func (m Map) ToFields() []Field {
 	}
 	return out
 }
+
+// BadKey is the key of the values given to Infow and the like without a string key before them
+const BadKey = "!BADKEY"
+
+// keyValueFields appends the alternating keys and values of Infow and the like to fields.
+// A Field or zap Field is taken as is, and a value that is not after a string key, like the last of an
+// odd count, is written under BadKey. The correlation id key is skipped like in WithField.
+func keyValueFields(fields []zapcore.Field, keysAndValues []interface{}) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields)+(len(keysAndValues)+1)/2)
+	out = append(out, fields...)
+	for i := 0; i < len(keysAndValues); i++ {
+		switch kv := keysAndValues[i].(type) {
+		case zapcore.Field:
+			if kv.Key != CorrelationID {
+				out = append(out, kv)
+			}
+		case Field:
+			if f := toField(kv); f.key != CorrelationID {
+				out = append(out, f.zap)
+			}
+		case string:
+			if i == len(keysAndValues)-1 {
+				out = append(out, zap.String(BadKey, kv))
+				continue
+			}
+			i++
+			if kv != CorrelationID {
+				out = append(out, zap.Any(kv, keysAndValues[i]))
+			}
+		default:
+			out = append(out, zap.Any(BadKey, kv))
+		}
+	}
+	return out
+}
@@ -3,6 +3,9 @@ package logger
 import (
 	"reflect"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
 )
 
 func TestKV_Key(t *testing.T) {
@@ -93,3 +96,113 @@ func TestMap_ToFields(t *testing.T) {
 		})
 	}
 }
+
+func TestLogger_keysAndValues(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l CorrelationLogger)
+		want map[string]interface{}
+	}{
+		{
+			name: "should pass; keys and values",
+			log: func(l CorrelationLogger) {
+				l.Infow("info", "user", "joe", "attempt", 2)
+			},
+			want: map[string]interface{}{"level": "info", "msg": "info", "user": "joe", "attempt": float64(2)},
+		},
+		{
+			name: "should pass; correlation id and fields are kept",
+			log: func(l CorrelationLogger) {
+				l.WithCorrelationID("cor_id").WithField("service", "api").Warnw("warn", "user", "joe")
+			},
+			want: map[string]interface{}{"level": "warn", "msg": "warn", CorrelationID: "cor_id", "service": "api", "user": "joe"},
+		},
+		{
+			name: "should pass; fields are taken as is",
+			log: func(l CorrelationLogger) {
+				l.Errorw("error", String("typed", "v"), KV{"kv", 1}, "user", "joe")
+			},
+			want: map[string]interface{}{"level": "error", "msg": "error", "typed": "v", "kv": float64(1), "user": "joe"},
+		},
+		{
+			name: "should pass; zap fields are taken as is",
+			log: func(l CorrelationLogger) {
+				l.WithCorrelationID("cor_id").Infow("info", zap.String("zap", "v"), zap.String(CorrelationID, "other"), "user", "joe")
+			},
+			want: map[string]interface{}{"level": "info", "msg": "info", CorrelationID: "cor_id", "zap": "v", "user": "joe"},
+		},
+		{
+			name: "should pass; odd count is written as bad key",
+			log: func(l CorrelationLogger) {
+				l.Infow("info", "user", "joe", "dangling")
+			},
+			want: map[string]interface{}{"level": "info", "msg": "info", "user": "joe", BadKey: "dangling"},
+		},
+		{
+			name: "should pass; non string key is written as bad key",
+			log: func(l CorrelationLogger) {
+				l.Infow("info", 42, "user", "joe")
+			},
+			want: map[string]interface{}{"level": "info", "msg": "info", BadKey: float64(42), "user": "joe"},
+		},
+		{
+			name: "should pass; correlation id key does not replace the logger's",
+			log: func(l CorrelationLogger) {
+				l.WithCorrelationID("cor_id").Infow("info", CorrelationID, "other")
+			},
+			want: map[string]interface{}{"level": "info", "msg": "info", CorrelationID: "cor_id"},
+		},
+		{
+			name: "should pass; debug is filtered by the level",
+			log: func(l CorrelationLogger) {
+				l.Debugw("debug", "user", "joe")
+				l.Infow("info")
+			},
+			want: map[string]interface{}{"level": "info", "msg": "info"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &syncBuffer{}
+			logr, err := New(WithLevel(info), WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(sb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.log(logr)
+			if err := logr.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := sb.lines(t, 1)[0]
+			delete(got, "ts")
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLogger_Panicw(t *testing.T) {
+	sb := &syncBuffer{}
+	logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(sb))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Panicw did not panic")
+			}
+		}()
+		logr.WithCorrelationID("cor_id").Panicw("panic", "user", "joe")
+	}()
+	if err := logr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	line := sb.lines(t, 1)[0]
+	if line["user"] != "joe" || line[CorrelationID] != "cor_id" {
+		t.Errorf("line = %v, want user joe and correlation id cor_id", line)
+	}
+}
@@ -1,6 +1,9 @@
 package logger
 
-import "go.uber.org/zap/zapcore"
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
 
 type LogLevel = zapcore.Level
 
@@ -22,3 +25,30 @@ const (
 	// FatalLevel logs a message, then calls os.Exit(1).
 	FatalLevel
 )
+
+// stepLevel moves the level by delta while keeping it between DebugLevel and FatalLevel
+func stepLevel(level zap.AtomicLevel, delta int) {
+	next := level.Level() + zapcore.Level(delta)
+	if next < DebugLevel {
+		next = DebugLevel
+	}
+	if next > FatalLevel {
+		next = FatalLevel
+	}
+	level.SetLevel(next)
+}
+
+// logAtLevel writes args to the logger method of the level.
+// DPanic and higher are written at the error level so they never panic or exit.
+func logAtLevel(l Logger, level LogLevel, args ...interface{}) {
+	switch {
+	case level <= DebugLevel:
+		l.Debug(args...)
+	case level == InfoLevel:
+		l.Info(args...)
+	case level == WarnLevel:
+		l.Warn(args...)
+	default:
+		l.Error(args...)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package logger
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// watchLevelSignals changes the level on SIGUSR1 and SIGUSR2 until ctx is done
+func watchLevelSignals(ctx context.Context, level zap.AtomicLevel) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case sig := <-sigs:
+				switch sig {
+				case syscall.SIGUSR1:
+					stepLevel(level, -1)
+				case syscall.SIGUSR2:
+					stepLevel(level, 1)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
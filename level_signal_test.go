@@ -0,0 +1,42 @@
+//go:build !windows
+
+package logger
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithLevelSignals(t *testing.T) {
+	logr, err := New(WithLevel(info), WithLevelSignals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logr.Close()
+
+	tests := []struct {
+		name string
+		sig  syscall.Signal
+		want LogLevel
+	}{
+		{name: "should pass; SIGUSR1 is more verbose", sig: syscall.SIGUSR1, want: DebugLevel},
+		{name: "should pass; SIGUSR2 is less verbose", sig: syscall.SIGUSR2, want: InfoLevel},
+		{name: "should pass; SIGUSR2 again", sig: syscall.SIGUSR2, want: WarnLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := syscall.Kill(syscall.Getpid(), tt.sig); err != nil {
+				t.Fatal(err)
+			}
+
+			deadline := time.Now().Add(time.Second * 5)
+			for logr.AtomicLevel().Level() != tt.want {
+				if time.Now().After(deadline) {
+					t.Fatalf("AtomicLevel() = %v, want %v", logr.AtomicLevel().Level(), tt.want)
+				}
+				time.Sleep(time.Millisecond * 10)
+			}
+		})
+	}
+}
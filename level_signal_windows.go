@@ -0,0 +1,14 @@
+//go:build windows
+
+package logger
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+func watchLevelSignals(ctx context.Context, level zap.AtomicLevel) error {
+	return errors.New("level signals are not supported on windows")
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_stepLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level zapcore.Level
+		delta int
+		want  zapcore.Level
+	}{
+		{name: "should pass; more verbose", level: InfoLevel, delta: -1, want: DebugLevel},
+		{name: "should pass; less verbose", level: InfoLevel, delta: 1, want: WarnLevel},
+		{name: "should pass; stays at debug", level: DebugLevel, delta: -1, want: DebugLevel},
+		{name: "should pass; stays at fatal", level: FatalLevel, delta: 1, want: FatalLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := zap.NewAtomicLevelAt(tt.level)
+			stepLevel(level, tt.delta)
+			if got := level.Level(); got != tt.want {
+				t.Errorf("stepLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogger_LevelHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantCode    int
+		want        zapcore.Level
+	}{
+		{
+			name:     "should pass; get the level",
+			method:   http.MethodGet,
+			wantCode: http.StatusOK,
+			want:     InfoLevel,
+		},
+		{
+			name:        "should pass; put a json body",
+			method:      http.MethodPut,
+			contentType: "application/json",
+			body:        `{"level":"debug"}`,
+			wantCode:    http.StatusOK,
+			want:        DebugLevel,
+		},
+		{
+			name:        "should pass; put a form body",
+			method:      http.MethodPut,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "level=error",
+			wantCode:    http.StatusOK,
+			want:        ErrorLevel,
+		},
+		{
+			name:        "should fail; unknown level",
+			method:      http.MethodPut,
+			contentType: "application/json",
+			body:        `{"level":"loud"}`,
+			wantCode:    http.StatusBadRequest,
+			want:        InfoLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logr, err := New(WithLevel(info))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer logr.Close()
+
+			req := httptest.NewRequest(tt.method, "/log/level", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			logr.LevelHandler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("LevelHandler() code = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if got := logr.AtomicLevel().Level(); got != tt.want {
+				t.Errorf("AtomicLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"syscall"
+
+	"go.uber.org/multierr"
+)
+
+// outputs holds what a logger made by New writes to.
+// The loggers derived from it with WithField, WithFields and WithCorrelationID share them
+// but only the logger returned by New closes them.
+type outputs struct {
+	// writers are synced or flushed by Sync
+	writers []io.Writer
+	files   []io.WriteCloser
+	async   *asyncSink
+	pipe    *pipeWriter
+	tail    *tail
+	cancel  context.CancelFunc
+	// recorder keeps the entries of WithFlightRecorder
+	recorder *flightRecorder
+	// limiter logs the summaries of WithRateLimit
+	limiter *rateLimiter
+	// dedup holds the repeats of WithDedup
+	dedup *deduplicator
+
+	once sync.Once
+	// closed is closed once the outputs are closed; err holds the result
+	closed chan struct{}
+	err    error
+}
+
+// sync waits for the entries written to the pipe to reach the writers and syncs the writers
+func (o *outputs) sync() error {
+	if o.pipe != nil {
+		o.pipe.wait()
+	}
+
+	var err error
+	for _, w := range o.writers {
+		err = multierr.Append(err, syncWriter(w))
+	}
+	return err
+}
+
+// syncWriter calls Sync or Flush when the writer has either
+func syncWriter(w io.Writer) error {
+	switch t := w.(type) {
+	case interface{ Sync() error }:
+		return ignoreSyncErr(t.Sync())
+	case interface{ Flush() error }:
+		return t.Flush()
+	case interface{ Flush() }:
+		t.Flush()
+	}
+	return nil
+}
+
+// ignoreSyncErr drops the errors returned when syncing a terminal or a pipe, like stderr often is
+func ignoreSyncErr(err error) error {
+	var out error
+	for _, e := range multierr.Errors(err) {
+		if errors.Is(e, syscall.EINVAL) || errors.Is(e, syscall.ENOTTY) {
+			continue
+		}
+		out = multierr.Append(out, e)
+	}
+	return out
+}
+
+// close drains the outputs and closes them once; the other calls wait for it and get the same result.
+// When ctx is done before the buffered entries are written the files are closed anyway.
+func (o *outputs) close(ctx context.Context, log SugaredLogger) error {
+	o.once.Do(func() {
+		defer close(o.closed)
+		o.cancel()
+		if o.limiter != nil {
+			// the last summary is written with the other entries
+			o.limiter.close()
+		}
+		if o.dedup != nil {
+			// the pending repeats are written before the outputs are drained
+			o.dedup.close()
+		}
+
+		drained := make(chan error, 1)
+		go func() {
+			err := ignoreSyncErr(log.Desugar().Sync())
+			if o.async != nil {
+				err = multierr.Append(err, o.async.Close())
+			}
+			if o.pipe != nil {
+				o.pipe.close()
+			}
+			for _, w := range o.writers {
+				err = multierr.Append(err, syncWriter(w))
+			}
+			drained <- err
+		}()
+
+		select {
+		case err := <-drained:
+			o.err = err
+		case <-ctx.Done():
+			o.err = ctx.Err()
+		}
+
+		if o.tail != nil {
+			// the Subscribe iterators end once they read what they buffered
+			o.tail.bus.Close()
+		}
+		for _, file := range o.files {
+			o.err = multierr.Append(o.err, file.Close())
+		}
+	})
+
+	select {
+	case <-o.closed:
+		return o.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeFiles closes the files opened before New failed
+func closeFiles(files []io.WriteCloser) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// pipeWriter is the write end of the pipe read by writeByNewLineSync.
+// It counts the bytes written and copied so Sync can wait for them to reach the writers.
+type pipeWriter struct {
+	w *io.PipeWriter
+
+	mu              sync.Mutex
+	cond            *sync.Cond
+	written, copied int64
+	done            bool
+	// finished is closed when writeByNewLineSync returns
+	finished chan struct{}
+}
+
+func newPipeWriter(w *io.PipeWriter) *pipeWriter {
+	p := &pipeWriter{w: w, finished: make(chan struct{})}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+func (p *pipeWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// the logger is closed so the entry is dropped
+		return len(b), nil
+	}
+
+	p.mu.Lock()
+	p.written += int64(n)
+	p.mu.Unlock()
+	return n, err
+}
+
+// copiedTo returns a writer that counts the bytes written to w
+func (p *pipeWriter) copiedTo(w io.Writer) io.Writer {
+	return copiedWriter{p: p, w: w}
+}
+
+// finish is called when the bytes are no longer copied
+func (p *pipeWriter) finish() {
+	p.mu.Lock()
+	p.done = true
+	p.mu.Unlock()
+	p.cond.Broadcast()
+	close(p.finished)
+}
+
+// wait returns once the bytes written so far are copied
+func (p *pipeWriter) wait() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	target := p.written
+	for p.copied < target && !p.done {
+		p.cond.Wait()
+	}
+}
+
+// close closes the pipe and waits for the copy to finish
+func (p *pipeWriter) close() {
+	p.w.Close()
+	<-p.finished
+}
+
+type copiedWriter struct {
+	p *pipeWriter
+	w io.Writer
+}
+
+func (c copiedWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+
+	c.p.mu.Lock()
+	c.p.copied += int64(n)
+	c.p.mu.Unlock()
+	c.p.cond.Broadcast()
+	return n, err
+}
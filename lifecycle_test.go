@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flushWriter counts the calls to Flush
+type flushWriter struct {
+	syncBuffer
+	mu      sync.Mutex
+	flushes int
+}
+
+func (w *flushWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flushes++
+	return nil
+}
+
+func TestLogger_Sync(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "should pass; writers",
+		},
+		{
+			name: "should pass; async writers",
+			opts: []Option{WithAsync(WithAsyncFlushInterval(time.Hour))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &flushWriter{}
+			encoded := &flushWriter{}
+			opts := append([]Option{
+				WithEncoding(jsonEncoder),
+				WithWriters(w),
+				WithWriterEncoding(encoded, logfmtEncoder),
+			}, tt.opts...)
+			logr, err := New(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer logr.Close()
+
+			for i := 0; i < 100; i++ {
+				logr.WithField("i", i).Info("entry")
+			}
+			// a derived logger syncs the shared outputs
+			if err := logr.WithCorrelationID("derived").(*logger).Sync(); err != nil {
+				t.Fatal(err)
+			}
+
+			// everything is written once Sync returns
+			if got := strings.Count(w.String(), "\n"); got != 100 {
+				t.Errorf("got %d lines, want 100", got)
+			}
+			if w.flushes == 0 || encoded.flushes == 0 {
+				t.Errorf("flushes = %d, %d; want the writers flushed", w.flushes, encoded.flushes)
+			}
+		})
+	}
+}
+
+func TestLogger_Close(t *testing.T) {
+	buf := &syncBuffer{}
+	logr, err := New(WithEncoding(jsonEncoder), WithWriters(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	derived := logr.WithField("key", "value").(*logger)
+	if err := derived.Close(); err != nil {
+		t.Fatalf("derived Close() error = %v", err)
+	}
+	derived.Info("derived still writes")
+	if err := logr.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "derived still writes") {
+		t.Fatalf("closing a derived logger closed the outputs: %s", buf.String())
+	}
+
+	logr.Info("before close")
+	if err := logr.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "before close") {
+		t.Errorf("Close() did not write the entries: %s", buf.String())
+	}
+
+	select {
+	case <-logr.outputs.pipe.finished:
+	default:
+		t.Error("the pipe goroutine is still running")
+	}
+
+	// closing again and logging after close are fine
+	if err := logr.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	logr.Info("after close")
+	derived.Info("after close")
+	if err := logr.Sync(); err != nil {
+		t.Errorf("Sync() after close error = %v", err)
+	}
+
+	if err := newNopLogger().Close(); err != nil {
+		t.Errorf("nop Close() error = %v", err)
+	}
+}
+
+func TestLogger_CloseContext(t *testing.T) {
+	w := newBlockingWriter()
+	logr, err := New(WithEncoding(jsonEncoder), WithWriters(w), WithAsync())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logr.Info("stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := logr.CloseContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CloseContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(w.release)
+	// the entry is still written once the writer is released
+	w.lines(t, 1)
+	if err := logr.Close(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() error = %v, want the first result", err)
+	}
+}
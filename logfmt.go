@@ -0,0 +1,469 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var _logfmtBufferPool = buffer.NewPool()
+
+// logfmtObjectEncoder writes entries as key=value pairs.
+// Nested objects are flattened into dotted keys (parent.child=value),
+// arrays are written as [a,b,c] and values are quoted when they contain
+// spaces, quotes, equal signs or control characters.
+type logfmtObjectEncoder struct {
+	*zapcore.EncoderConfig
+	buf *buffer.Buffer
+	// prefixes holds the keys of the namespaces and objects that are open
+	prefixes []string
+}
+
+// NewLogfmtEncoder creates an encoder that writes logfmt lines
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtObjectEncoder{
+		EncoderConfig: &cfg,
+		buf:           _logfmtBufferPool.Get(),
+	}
+}
+
+func (enc *logfmtObjectEncoder) addKey(key string) {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+	for _, prefix := range enc.prefixes {
+		enc.appendKey(prefix)
+		enc.buf.AppendByte('.')
+	}
+	enc.appendKey(key)
+	enc.buf.AppendByte('=')
+}
+
+// appendKey writes the key replacing the characters that would break the line apart
+func (enc *logfmtObjectEncoder) appendKey(key string) {
+	if key == "" {
+		enc.buf.AppendByte('_')
+		return
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			enc.buf.AppendByte('_')
+			continue
+		}
+		enc.buf.AppendByte(key[i])
+	}
+}
+
+func (enc *logfmtObjectEncoder) appendValue(s string) {
+	if needsQuote(s) {
+		enc.buf.AppendString(strconv.Quote(s))
+		return
+	}
+	enc.buf.AppendString(s)
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+func (enc *logfmtObjectEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	arr := newLogfmtArrayEncoder(enc.EncoderConfig)
+	defer arr.free()
+	err := marshaler.MarshalLogArray(arr)
+	enc.addKey(key)
+	enc.appendValue(arr.String())
+	return err
+}
+
+func (enc *logfmtObjectEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	enc.prefixes = append(enc.prefixes, key)
+	err := marshaler.MarshalLogObject(enc)
+	enc.prefixes = enc.prefixes[:len(enc.prefixes)-1]
+	return err
+}
+
+func (enc *logfmtObjectEncoder) AddBinary(key string, value []byte) {
+	enc.AddString(key, base64.StdEncoding.EncodeToString(value))
+}
+
+func (enc *logfmtObjectEncoder) AddByteString(key string, value []byte) {
+	enc.AddString(key, string(value))
+}
+
+func (enc *logfmtObjectEncoder) AddBool(key string, value bool) {
+	enc.addKey(key)
+	enc.buf.AppendBool(value)
+}
+
+func (enc *logfmtObjectEncoder) AddComplex128(key string, value complex128) {
+	enc.addKey(key)
+	enc.appendValue(strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+func (enc *logfmtObjectEncoder) AddComplex64(key string, value complex64) {
+	enc.addKey(key)
+	enc.appendValue(strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+func (enc *logfmtObjectEncoder) AddDuration(key string, value time.Duration) {
+	enc.addKey(key)
+	enc.appendValue(encodeDuration(enc.EncoderConfig, value))
+}
+
+func (enc *logfmtObjectEncoder) AddFloat64(key string, value float64) {
+	enc.addKey(key)
+	enc.buf.AppendString(formatFloat(value, 64))
+}
+
+func (enc *logfmtObjectEncoder) AddFloat32(key string, value float32) {
+	enc.addKey(key)
+	enc.buf.AppendString(formatFloat(float64(value), 32))
+}
+
+func (enc *logfmtObjectEncoder) AddInt(key string, value int)     { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtObjectEncoder) AddInt32(key string, value int32) { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtObjectEncoder) AddInt16(key string, value int16) { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtObjectEncoder) AddInt8(key string, value int8)   { enc.AddInt64(key, int64(value)) }
+
+func (enc *logfmtObjectEncoder) AddInt64(key string, value int64) {
+	enc.addKey(key)
+	enc.buf.AppendInt(value)
+}
+
+func (enc *logfmtObjectEncoder) AddString(key, value string) {
+	enc.addKey(key)
+	enc.appendValue(value)
+}
+
+func (enc *logfmtObjectEncoder) AddTime(key string, value time.Time) {
+	enc.addKey(key)
+	enc.appendValue(encodeTime(enc.EncoderConfig, value))
+}
+
+func (enc *logfmtObjectEncoder) AddUint(key string, value uint) { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtObjectEncoder) AddUint32(key string, value uint32) {
+	enc.AddUint64(key, uint64(value))
+}
+func (enc *logfmtObjectEncoder) AddUint16(key string, value uint16) {
+	enc.AddUint64(key, uint64(value))
+}
+func (enc *logfmtObjectEncoder) AddUint8(key string, value uint8) { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtObjectEncoder) AddUintptr(key string, value uintptr) {
+	enc.AddUint64(key, uint64(value))
+}
+
+func (enc *logfmtObjectEncoder) AddUint64(key string, value uint64) {
+	enc.addKey(key)
+	enc.buf.AppendUint(value)
+}
+
+func (enc *logfmtObjectEncoder) AddReflected(key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	enc.addKey(key)
+	enc.appendValue(string(b))
+	return nil
+}
+
+func (enc *logfmtObjectEncoder) OpenNamespace(key string) {
+	enc.prefixes = append(enc.prefixes, key)
+}
+
+func (enc *logfmtObjectEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtObjectEncoder{
+		EncoderConfig: enc.EncoderConfig,
+		buf:           _logfmtBufferPool.Get(),
+		prefixes:      append([]string{}, enc.prefixes...),
+	}
+	clone.buf.Write(enc.buf.Bytes())
+	return clone
+}
+
+func (enc *logfmtObjectEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtObjectEncoder{
+		EncoderConfig: enc.EncoderConfig,
+		buf:           _logfmtBufferPool.Get(),
+	}
+
+	if final.TimeKey != "" {
+		final.AddTime(final.TimeKey, ent.Time)
+	}
+	if final.LevelKey != "" {
+		final.addKey(final.LevelKey)
+		final.appendValue(encodePrimitive(func(arr zapcore.PrimitiveArrayEncoder) {
+			if final.EncodeLevel == nil {
+				arr.AppendString(ent.Level.String())
+				return
+			}
+			final.EncodeLevel(ent.Level, arr)
+		}))
+	}
+	if ent.LoggerName != "" && final.NameKey != "" {
+		final.addKey(final.NameKey)
+		final.appendValue(encodePrimitive(func(arr zapcore.PrimitiveArrayEncoder) {
+			if final.EncodeName == nil {
+				arr.AppendString(ent.LoggerName)
+				return
+			}
+			final.EncodeName(ent.LoggerName, arr)
+		}))
+	}
+	if ent.Caller.Defined {
+		if final.CallerKey != "" {
+			final.addKey(final.CallerKey)
+			final.appendValue(encodePrimitive(func(arr zapcore.PrimitiveArrayEncoder) {
+				if final.EncodeCaller == nil {
+					arr.AppendString(ent.Caller.TrimmedPath())
+					return
+				}
+				final.EncodeCaller(ent.Caller, arr)
+			}))
+		}
+		if final.FunctionKey != "" {
+			final.AddString(final.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if final.MessageKey != "" {
+		final.AddString(final.MessageKey, ent.Message)
+	}
+
+	if enc.buf.Len() > 0 {
+		if final.buf.Len() > 0 {
+			final.buf.AppendByte(' ')
+		}
+		final.buf.Write(enc.buf.Bytes())
+	}
+	// fields are added under the namespaces opened by the context
+	final.prefixes = append(final.prefixes, enc.prefixes...)
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	final.prefixes = nil
+
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		final.AddString(final.StacktraceKey, ent.Stack)
+	}
+
+	if final.LineEnding != "" {
+		final.buf.AppendString(final.LineEnding)
+	} else {
+		final.buf.AppendString(zapcore.DefaultLineEnding)
+	}
+
+	return final.buf, nil
+}
+
+// logfmtArrayEncoder writes the elements of an array as [a,b,c].
+// Objects in an array are written as {key=value key=value}.
+type logfmtArrayEncoder struct {
+	cfg *zapcore.EncoderConfig
+	buf *buffer.Buffer
+	n   int
+}
+
+func newLogfmtArrayEncoder(cfg *zapcore.EncoderConfig) *logfmtArrayEncoder {
+	arr := &logfmtArrayEncoder{
+		cfg: cfg,
+		buf: _logfmtBufferPool.Get(),
+	}
+	arr.buf.AppendByte('[')
+	return arr
+}
+
+func (arr *logfmtArrayEncoder) String() string {
+	return arr.buf.String() + "]"
+}
+
+func (arr *logfmtArrayEncoder) free() {
+	arr.buf.Free()
+}
+
+func (arr *logfmtArrayEncoder) sep() {
+	if arr.n > 0 {
+		arr.buf.AppendByte(',')
+	}
+	arr.n++
+}
+
+func (arr *logfmtArrayEncoder) appendElem(s string) {
+	arr.sep()
+	if needsQuote(s) || strings.ContainsAny(s, ",[]{}") {
+		arr.buf.AppendString(strconv.Quote(s))
+		return
+	}
+	arr.buf.AppendString(s)
+}
+
+func (arr *logfmtArrayEncoder) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	inner := newLogfmtArrayEncoder(arr.cfg)
+	defer inner.free()
+	err := marshaler.MarshalLogArray(inner)
+	arr.sep()
+	arr.buf.AppendString(inner.String())
+	return err
+}
+
+func (arr *logfmtArrayEncoder) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	obj := &logfmtObjectEncoder{
+		EncoderConfig: arr.cfg,
+		buf:           _logfmtBufferPool.Get(),
+	}
+	defer obj.buf.Free()
+	err := marshaler.MarshalLogObject(obj)
+	arr.sep()
+	arr.buf.AppendByte('{')
+	arr.buf.Write(obj.buf.Bytes())
+	arr.buf.AppendByte('}')
+	return err
+}
+
+func (arr *logfmtArrayEncoder) AppendReflected(value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	arr.sep()
+	arr.buf.Write(b)
+	return nil
+}
+
+func (arr *logfmtArrayEncoder) AppendBool(value bool) {
+	arr.sep()
+	arr.buf.AppendBool(value)
+}
+
+func (arr *logfmtArrayEncoder) AppendByteString(value []byte) { arr.appendElem(string(value)) }
+
+func (arr *logfmtArrayEncoder) AppendComplex128(value complex128) {
+	arr.appendElem(strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+func (arr *logfmtArrayEncoder) AppendComplex64(value complex64) {
+	arr.appendElem(strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+func (arr *logfmtArrayEncoder) AppendFloat64(value float64) {
+	arr.sep()
+	arr.buf.AppendString(formatFloat(value, 64))
+}
+
+func (arr *logfmtArrayEncoder) AppendFloat32(value float32) {
+	arr.sep()
+	arr.buf.AppendString(formatFloat(float64(value), 32))
+}
+
+func (arr *logfmtArrayEncoder) AppendInt(value int)     { arr.AppendInt64(int64(value)) }
+func (arr *logfmtArrayEncoder) AppendInt32(value int32) { arr.AppendInt64(int64(value)) }
+func (arr *logfmtArrayEncoder) AppendInt16(value int16) { arr.AppendInt64(int64(value)) }
+func (arr *logfmtArrayEncoder) AppendInt8(value int8)   { arr.AppendInt64(int64(value)) }
+
+func (arr *logfmtArrayEncoder) AppendInt64(value int64) {
+	arr.sep()
+	arr.buf.AppendInt(value)
+}
+
+func (arr *logfmtArrayEncoder) AppendString(value string) { arr.appendElem(value) }
+
+func (arr *logfmtArrayEncoder) AppendUint(value uint)       { arr.AppendUint64(uint64(value)) }
+func (arr *logfmtArrayEncoder) AppendUint32(value uint32)   { arr.AppendUint64(uint64(value)) }
+func (arr *logfmtArrayEncoder) AppendUint16(value uint16)   { arr.AppendUint64(uint64(value)) }
+func (arr *logfmtArrayEncoder) AppendUint8(value uint8)     { arr.AppendUint64(uint64(value)) }
+func (arr *logfmtArrayEncoder) AppendUintptr(value uintptr) { arr.AppendUint64(uint64(value)) }
+
+func (arr *logfmtArrayEncoder) AppendUint64(value uint64) {
+	arr.sep()
+	arr.buf.AppendUint(value)
+}
+
+func (arr *logfmtArrayEncoder) AppendDuration(value time.Duration) {
+	arr.appendElem(encodeDuration(arr.cfg, value))
+}
+
+func (arr *logfmtArrayEncoder) AppendTime(value time.Time) {
+	arr.appendElem(encodeTime(arr.cfg, value))
+}
+
+// primitiveEncoder captures the single value written by the EncoderConfig callbacks
+type primitiveEncoder struct {
+	values []string
+}
+
+func encodePrimitive(f func(zapcore.PrimitiveArrayEncoder)) string {
+	enc := &primitiveEncoder{}
+	f(enc)
+	return strings.Join(enc.values, " ")
+}
+
+func (p *primitiveEncoder) add(s string) { p.values = append(p.values, s) }
+
+func (p *primitiveEncoder) AppendBool(v bool)         { p.add(strconv.FormatBool(v)) }
+func (p *primitiveEncoder) AppendByteString(v []byte) { p.add(string(v)) }
+func (p *primitiveEncoder) AppendComplex128(v complex128) {
+	p.add(strconv.FormatComplex(v, 'g', -1, 128))
+}
+func (p *primitiveEncoder) AppendComplex64(v complex64) {
+	p.add(strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+func (p *primitiveEncoder) AppendFloat64(v float64)        { p.add(formatFloat(v, 64)) }
+func (p *primitiveEncoder) AppendFloat32(v float32)        { p.add(formatFloat(float64(v), 32)) }
+func (p *primitiveEncoder) AppendInt(v int)                { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveEncoder) AppendInt64(v int64)            { p.add(strconv.FormatInt(v, 10)) }
+func (p *primitiveEncoder) AppendInt32(v int32)            { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveEncoder) AppendInt16(v int16)            { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveEncoder) AppendInt8(v int8)              { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveEncoder) AppendString(v string)          { p.add(v) }
+func (p *primitiveEncoder) AppendUint(v uint)              { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveEncoder) AppendUint64(v uint64)          { p.add(strconv.FormatUint(v, 10)) }
+func (p *primitiveEncoder) AppendUint32(v uint32)          { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveEncoder) AppendUint16(v uint16)          { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveEncoder) AppendUint8(v uint8)            { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveEncoder) AppendUintptr(v uintptr)        { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveEncoder) AppendDuration(v time.Duration) { p.add(v.String()) }
+func (p *primitiveEncoder) AppendTime(v time.Time)         { p.add(v.Format(time.RFC3339Nano)) }
+
+func encodeTime(cfg *zapcore.EncoderConfig, t time.Time) string {
+	if cfg.EncodeTime == nil {
+		return t.Format(time.RFC3339Nano)
+	}
+	return encodePrimitive(func(arr zapcore.PrimitiveArrayEncoder) {
+		cfg.EncodeTime(t, arr)
+	})
+}
+
+func encodeDuration(cfg *zapcore.EncoderConfig, d time.Duration) string {
+	if cfg.EncodeDuration == nil {
+		return d.String()
+	}
+	return encodePrimitive(func(arr zapcore.PrimitiveArrayEncoder) {
+		cfg.EncodeDuration(d, arr)
+	})
+}
+
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'f', -1, bitSize)
+}
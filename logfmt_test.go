@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testUser struct {
+	Name  string
+	Email string
+}
+
+func (u testUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	enc.AddString("email", u.Email)
+	return nil
+}
+
+func TestLogfmtEncoder_EncodeEntry(t *testing.T) {
+	ts := time.Date(2022, 8, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		context []zapcore.Field
+		fields  []zapcore.Field
+		msg     string
+		want    string
+	}{
+		{
+			name: "should pass; plain values",
+			msg:  "hello",
+			fields: []zapcore.Field{
+				zap.String("key", "value"),
+				zap.Int("count", 3),
+				zap.Bool("ok", true),
+				zap.Duration("took", time.Second),
+			},
+			want: `ts=2022-08-01T12:30:00.000Z level=info msg=hello key=value count=3 ok=true took=1` + "\n",
+		},
+		{
+			name: "should pass; quotes and escapes",
+			msg:  "hello world",
+			fields: []zapcore.Field{
+				zap.String("quote", `say "hi"`),
+				zap.String("equals", "a=b"),
+				zap.String("empty", ""),
+				zap.String("multi line", "a\nb"),
+			},
+			want: `ts=2022-08-01T12:30:00.000Z level=info msg="hello world" quote="say \"hi\"" equals="a=b" empty="" multi_line="a\nb"` + "\n",
+		},
+		{
+			name: "should pass; nested objects and namespaces",
+			msg:  "nested",
+			context: []zapcore.Field{
+				zap.String(CorrelationID, "cor_id"),
+				zap.Namespace("req"),
+			},
+			fields: []zapcore.Field{
+				zap.Object("user", testUser{Name: "joe", Email: "joe@example.com"}),
+			},
+			want: `ts=2022-08-01T12:30:00.000Z level=info msg=nested correlation_id=cor_id req.user.name=joe req.user.email=joe@example.com` + "\n",
+		},
+		{
+			name: "should pass; arrays",
+			msg:  "arrays",
+			fields: []zapcore.Field{
+				zap.Ints("ints", []int{1, 2, 3}),
+				zap.Strings("strs", []string{"a", "b c", "d,e"}),
+				zap.Objects("users", []testUser{{Name: "a", Email: "b"}}),
+			},
+			want: `ts=2022-08-01T12:30:00.000Z level=info msg=arrays ints=[1,2,3] strs="[a,\"b c\",\"d,e\"]" users="[{name=a email=b}]"` + "\n",
+		},
+		{
+			name: "should pass; errors and reflected values",
+			msg:  "errors",
+			fields: []zapcore.Field{
+				zap.Error(errors.New("it broke")),
+				zap.Any("map", map[string]int{"a": 1}),
+			},
+			want: `ts=2022-08-01T12:30:00.000Z level=info msg=errors error="it broke" map="{\"a\":1}"` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := zap.NewProductionEncoderConfig()
+			cfg.EncodeTime = zapcore.ISO8601TimeEncoder
+			enc := NewLogfmtEncoder(cfg)
+			for _, f := range tt.context {
+				f.AddTo(enc)
+			}
+
+			buf, err := enc.Clone().EncodeEntry(zapcore.Entry{
+				Level:   zapcore.InfoLevel,
+				Time:    ts,
+				Message: tt.msg,
+			}, tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("EncodeEntry() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_logfmt(t *testing.T) {
+	buf := &syncBuffer{}
+	logr, err := New(WithEncoding(logfmtEncoder), WithWriters(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logr.WithCorrelationID("cor_id").Info("hello")
+
+	deadline := time.Now().Add(time.Second * 5)
+	for buf.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	want := "level=info msg=hello correlation_id=cor_id\n"
+	got := buf.String()
+	if len(got) < len(want) || got[len(got)-len(want):] != want {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+
+	if _, err := New(WithEncoding(logfmtEncoder)); err != nil {
+		t.Errorf("New() without writers error = %v", err)
+	}
+}
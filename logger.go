@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
+	"net/http"
+	"sort"
 	"strings"
 
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -39,11 +41,19 @@ type Logger interface {
 	Panicf(format string, args ...interface{})
 	Warn(args ...interface{})
 	Warnf(format string, args ...interface{})
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	DPanicw(msg string, keysAndValues ...interface{})
+	Panicw(msg string, keysAndValues ...interface{})
+	Fatalw(msg string, keysAndValues ...interface{})
 }
 
 type CorrelationLogger interface {
 	Logger
 	FieldLogger
+	ContextLogger
 	WithCorrelationID(id string) CorrelationLogger
 }
 
@@ -54,16 +64,29 @@ type SugaredLogger interface {
 
 type logger struct {
 	log           SugaredLogger
+	level         zap.AtomicLevel
 	correlationID string
 	fields        fields
-	files         []*os.File
-	cancel        context.CancelFunc
+	outputs       *outputs
+	// owner is set on the logger returned by New, the only one that closes the outputs
+	owner bool
+	// traceCorrelation uses the trace id as the correlation id when there is none
+	traceCorrelation bool
+	// idGenerator makes the correlation ids of calls without one; newID is used when nil
+	idGenerator func() string
+	// zapFields are the correlation id and fields as they are written, made once by setFields
+	zapFields []zapcore.Field
+	// base is log desugared once, as Desugar copies the zap logger on every call
+	base *zap.Logger
+	// name is the name given to base by Named, which zap does not expose
+	name string
 }
 
 type FieldLogger interface {
 	Logger
 	WithField(key string, value interface{}) FieldLogger
 	WithFields(in ...Field) FieldLogger
+	WithTypedFields(in ...TypedField) FieldLogger
 }
 
 func New(opts ...Option) (*logger, error) {
@@ -83,15 +106,47 @@ func newLogger(opts ...Option) (*logger, error) {
 		}
 	}
 
+	var redact *redactor
+	if len(config.redactRules) != 0 {
+		var err error
+		if redact, err = newRedactor(config.redactRules, config.redactHashKey); err != nil {
+			return nil, err
+		}
+		config.zap.InitialFields = redact.initialFields(config.zap.InitialFields)
+	}
+
 	buildOpts := []zap.Option{
 		zap.WithCaller(false),
 	}
 
-	files := []*os.File{}
+	files := []io.WriteCloser{}
 
 	var reader *io.PipeReader
+	var pipe *pipeWriter
+	var async *asyncSink
 
-	if len(config.writers) != 0 {
+	// zapOutputs is set when the core zap builds is the one writing to the output paths
+	zapOutputs := config.async == nil && len(config.writers) == 0
+
+	if config.async != nil {
+		out, outFiles, err := asyncOutputs(config)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, outFiles...)
+		// the sink writes to the outputs so zap does not need to open them
+		config.zap.OutputPaths = []string{}
+
+		enc, err := newEncoder(config.zap.Encoding, config.zap.EncoderConfig)
+		if err != nil {
+			return nil, err
+		}
+		async = newAsyncSink(*config.async, out)
+		core := newAsyncCore(enc, async, config.zap.Level).With(initialFields(config))
+		buildOpts = append(buildOpts, zap.WrapCore(func(zapcore.Core) zapcore.Core {
+			return core
+		}))
+	} else if len(config.writers) != 0 {
 
 		for _, output := range config.zap.OutputPaths {
 			// TODO: find all of the non-filepath outputs and continue
@@ -99,9 +154,10 @@ func newLogger(opts ...Option) (*logger, error) {
 				continue
 			}
 
-			f, err := os.Create(output)
+			f, err := newRotatingFile(output, config.rotation)
 			if err != nil {
-				return nil, fmt.Errorf("create: %v", err)
+				closeFiles(files)
+				return nil, err
 			}
 
 			config.writers = append(config.writers, f)
@@ -110,32 +166,167 @@ func newLogger(opts ...Option) (*logger, error) {
 
 		var writer *io.PipeWriter
 		reader, writer = io.Pipe()
-		f, err := newCore(config, writer)
+		pipe = newPipeWriter(writer)
+		f, err := newCore(config, pipe)
+		if err != nil {
+			return nil, err
+		}
+		buildOpts = append(buildOpts, zap.WrapCore(f))
+	} else if config.rotation.enabled() {
+		// zap opens the output paths itself so the files are pulled out
+		// and written by a rotating file core next to it
+		outputs := []string{}
+		syncers := []zapcore.WriteSyncer{}
+		for _, output := range config.zap.OutputPaths {
+			if output == "stderr" || output == "stdout" {
+				outputs = append(outputs, output)
+				continue
+			}
+
+			f, err := newRotatingFile(output, config.rotation)
+			if err != nil {
+				closeFiles(files)
+				return nil, err
+			}
+			files = append(files, f)
+			syncers = append(syncers, f)
+		}
+		config.zap.OutputPaths = outputs
+
+		if len(syncers) != 0 {
+			f, err := newTeeCore(config, config.zap.Encoding, zapcore.NewMultiWriteSyncer(syncers...))
+			if err != nil {
+				return nil, err
+			}
+			buildOpts = append(buildOpts, zap.WrapCore(f))
+		}
+	}
+
+	buildConfig := *config.zap
+	if !zapEncoder(config.zap.Encoding) {
+		// zap only builds its own encoders, so the outputs left for it are written by a core of ours
+		if zapOutputs && len(config.zap.OutputPaths) != 0 {
+			sink, closeSink, err := zap.Open(config.zap.OutputPaths...)
+			if err != nil {
+				closeFiles(files)
+				return nil, err
+			}
+			files = append(files, sinkCloser{WriteSyncer: sink, close: closeSink})
+			f, err := newCore(config, sink)
+			if err != nil {
+				closeFiles(files)
+				return nil, err
+			}
+			buildOpts = append(buildOpts, zap.WrapCore(f))
+		}
+		// the core zap builds is replaced, it only needs an encoder zap knows
+		buildConfig.Encoding = jsonEncoder
+		buildConfig.OutputPaths = []string{}
+	}
+
+	for _, ew := range config.encodedWriters {
+		f, err := newTeeCore(config, ew.encoding, zapcore.AddSync(ew.writer))
+		if err != nil {
+			return nil, err
+		}
+		buildOpts = append(buildOpts, zap.WrapCore(f))
+	}
+
+	for _, bus := range config.eventBuses {
+		f, err := newEventCore(config, bus)
 		if err != nil {
 			return nil, err
 		}
 		buildOpts = append(buildOpts, zap.WrapCore(f))
 	}
 
-	logr, err := config.zap.Build(
+	logTail := newTail()
+	buildOpts = append(buildOpts, zap.WrapCore(newTailCore(config, logTail)))
+
+	if config.sampling != nil {
+		buildOpts = append(buildOpts, zap.WrapCore(newSamplerCore(*config.sampling)))
+	}
+
+	var dedup *deduplicator
+	if config.dedupWindow > 0 {
+		dedup = newDeduplicator(config.dedupWindow)
+		buildOpts = append(buildOpts, zap.WrapCore(newDedupCore(dedup)))
+	}
+
+	var recorder *flightRecorder
+	if config.flightRecorder != nil {
+		// wraps every other core so it sees the entries they do not write
+		recorder = newFlightRecorder(*config.flightRecorder)
+		buildOpts = append(buildOpts, zap.WrapCore(newRecorderCore(recorder)))
+	}
+
+	if redact != nil {
+		// wraps every other core so none of them gets the fields before they are redacted
+		buildOpts = append(buildOpts, zap.WrapCore(newRedactCore(redact)))
+	}
+
+	logr, err := buildConfig.Build(
 		buildOpts...,
 	)
 	if err != nil {
+		if async != nil {
+			async.Close()
+		}
+		if dedup != nil {
+			dedup.close()
+		}
 		return nil, fmt.Errorf("build: %v", err)
 	}
 	sugar := logr.Sugar()
 
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	// Start the Reader
 	if reader != nil {
-		go writeByNewLineSync(sugar, reader, config.writers...)
+		go func() {
+			defer pipe.finish()
+			writeByNewLineSync(sugar, reader, pipe.copiedTo(io.MultiWriter(config.writers...)))
+		}()
+	}
+
+	if config.levelSignals {
+		if err := watchLevelSignals(ctx, config.zap.Level); err != nil {
+			cancel()
+			return nil, fmt.Errorf("level signals: %v", err)
+		}
+	}
+
+	var limiter *rateLimiter
+	if config.rateLimit != nil {
+		limiter = newRateLimiter(*config.rateLimit)
+		limiter.start(logr)
+	}
+
+	writers := append([]io.Writer{}, config.writers...)
+	for _, ew := range config.encodedWriters {
+		writers = append(writers, ew.writer)
 	}
 
 	return &logger{
 		log:    sugar,
-		files:  files,
+		base:   logr,
+		level:  config.zap.Level,
 		fields: fields{},
-		cancel: cancel,
+		outputs: &outputs{
+			writers:  writers,
+			files:    files,
+			async:    async,
+			pipe:     pipe,
+			tail:     logTail,
+			recorder: recorder,
+			limiter:  limiter,
+			dedup:    dedup,
+			cancel:   cancel,
+			closed:   make(chan struct{}),
+		},
+		owner: true,
+
+		traceCorrelation: config.traceCorrelation,
+		idGenerator:      config.idGenerator,
 	}, err
 }
 
@@ -145,155 +336,301 @@ func NewCorrelationLogger(opts ...Option) (*logger, error) {
 }
 
 func (l *logger) Debug(args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	msg := argsToString(args)
+	if !l.allow(DebugLevel, msg) {
+		return
+	}
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Debug(argsToString(args), fields...)
+		l.desugared().Debug(msg, fields...)
 		return
 	}
-	l.log.Debug(argsToString(args))
+	l.log.Debug(msg)
 }
 
 func (l *logger) Debugf(format string, args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	if !l.allow(DebugLevel, format) {
+		return
+	}
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Debug(fmt.Sprintf(format, args...), fields...)
+		l.desugared().Debug(fmt.Sprintf(format, args...), fields...)
 		return
 	}
 	l.log.Debugf(format, args...)
 }
 
 func (l *logger) DPanic(args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().DPanic(argsToString(args), fields...)
+		l.desugared().DPanic(argsToString(args), fields...)
 		return
 	}
 	l.log.DPanic(argsToString(args))
 }
 
 func (l *logger) DPanicf(format string, args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().DPanic(fmt.Sprintf(format, args...), fields...)
+		l.desugared().DPanic(fmt.Sprintf(format, args...), fields...)
 		return
 	}
 	l.log.DPanicf(format, args...)
 }
 
 func (l *logger) Error(args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	msg := argsToString(args)
+	if !l.allow(ErrorLevel, msg) {
+		return
+	}
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Error(argsToString(args), fields...)
+		l.desugared().Error(msg, fields...)
 		return
 	}
-	l.log.Error(argsToString(args))
+	l.log.Error(msg)
 }
 
 func (l *logger) Errorf(format string, args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	if !l.allow(ErrorLevel, format) {
+		return
+	}
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Error(fmt.Sprintf(format, args...), fields...)
+		l.desugared().Error(fmt.Sprintf(format, args...), fields...)
 		return
 	}
 	l.log.Errorf(format, args...)
 }
 
 func (l *logger) Fatal(args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Fatal(argsToString(args), fields...)
+		l.desugared().Fatal(argsToString(args), fields...)
 		return
 	}
 	l.log.Fatal(argsToString(args))
 }
 
 func (l *logger) Fatalf(format string, args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Fatal(fmt.Sprintf(format, args...), fields...)
+		l.desugared().Fatal(fmt.Sprintf(format, args...), fields...)
 		return
 	}
 	l.log.Fatalf(format, args...)
 }
 
 func (l *logger) Info(args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	msg := argsToString(args)
+	if !l.allow(InfoLevel, msg) {
+		return
+	}
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.
-			Desugar().
-			Info(argsToString(args), fields...)
+		l.desugared().Info(msg, fields...)
 		return
 	}
-	l.log.Info(argsToString(args))
+	l.log.Info(msg)
 }
 
 func (l *logger) Infof(format string, args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	if !l.allow(InfoLevel, format) {
+		return
+	}
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Info(fmt.Sprintf(format, args...), fields...)
+		l.desugared().Info(fmt.Sprintf(format, args...), fields...)
 		return
 	}
 	l.log.Infof(format, args...)
 }
 
 func (l *logger) Warn(args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	msg := argsToString(args)
+	if !l.allow(WarnLevel, msg) {
+		return
+	}
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Warn(argsToString(args), fields...)
+		l.desugared().Warn(msg, fields...)
 		return
 	}
-	l.log.Warn(argsToString(args))
+	l.log.Warn(msg)
 }
 
 func (l *logger) Warnf(format string, args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	if !l.allow(WarnLevel, format) {
+		return
+	}
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Warn(fmt.Sprintf(format, args...), fields...)
+		l.desugared().Warn(fmt.Sprintf(format, args...), fields...)
 		return
 	}
 	l.log.Warnf(format, args...)
 }
 
 func (l *logger) Panic(args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	fields := l.zapFields
 
 	if len(fields) > 0 {
-		l.log.Desugar().Panic(argsToString(args), fields...)
+		l.desugared().Panic(argsToString(args), fields...)
 		return
 	}
 	l.log.Panic(argsToString(args))
 }
 
 func (l *logger) Panicf(format string, args ...interface{}) {
-	fields := getFields(l.correlationID, l.fields)
+	fields := l.zapFields
 	if len(fields) > 0 {
-		l.log.Desugar().Panic(fmt.Sprintf(format, args...), fields...)
+		l.desugared().Panic(fmt.Sprintf(format, args...), fields...)
 		return
 	}
 	l.log.Panicf(format, args...)
 }
 
+// Debugw logs the message with alternating keys and values, like "user", id, after the fields of the logger.
+// A value without a string key before it is written under BadKey.
+func (l *logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if !l.allow(DebugLevel, msg) {
+		return
+	}
+	if ce := l.desugared().Check(DebugLevel, msg); ce != nil {
+		ce.Write(keyValueFields(l.zapFields, keysAndValues)...)
+	}
+}
+
+// Infow is Debugw at the info level
+func (l *logger) Infow(msg string, keysAndValues ...interface{}) {
+	if !l.allow(InfoLevel, msg) {
+		return
+	}
+	if ce := l.desugared().Check(InfoLevel, msg); ce != nil {
+		ce.Write(keyValueFields(l.zapFields, keysAndValues)...)
+	}
+}
+
+// Warnw is Debugw at the warn level
+func (l *logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if !l.allow(WarnLevel, msg) {
+		return
+	}
+	if ce := l.desugared().Check(WarnLevel, msg); ce != nil {
+		ce.Write(keyValueFields(l.zapFields, keysAndValues)...)
+	}
+}
+
+// Errorw is Debugw at the error level
+func (l *logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if !l.allow(ErrorLevel, msg) {
+		return
+	}
+	if ce := l.desugared().Check(ErrorLevel, msg); ce != nil {
+		ce.Write(keyValueFields(l.zapFields, keysAndValues)...)
+	}
+}
+
+// DPanicw is Debugw at the dpanic level
+func (l *logger) DPanicw(msg string, keysAndValues ...interface{}) {
+	if ce := l.desugared().Check(DPanicLevel, msg); ce != nil {
+		ce.Write(keyValueFields(l.zapFields, keysAndValues)...)
+	}
+}
+
+// Panicw is Debugw at the panic level
+func (l *logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if ce := l.desugared().Check(PanicLevel, msg); ce != nil {
+		ce.Write(keyValueFields(l.zapFields, keysAndValues)...)
+	}
+}
+
+// Fatalw is Debugw at the fatal level
+func (l *logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if ce := l.desugared().Check(FatalLevel, msg); ce != nil {
+		ce.Write(keyValueFields(l.zapFields, keysAndValues)...)
+	}
+}
+
 func (l *logger) WithCorrelationID(id string) CorrelationLogger {
+	out := l.derive()
+	out.setFields(id, l.fields)
+	return out
+}
+
+// Named adds name to the name of the logger, joined with a dot.
+// The name is written in the logger field and is part of the WithEventBus topics.
+func (l *logger) Named(name string) CorrelationLogger {
+	out := l.derive()
+	out.base = l.desugared().Named(name)
+	out.log = out.base.Sugar()
+	switch {
+	case name == "":
+	case l.name == "":
+		out.name = name
+	default:
+		out.name = l.name + "." + name
+	}
+	return out
+}
+
+// derive returns a copy of l that shares its outputs and level
+func (l *logger) derive() *logger {
 	return &logger{
-		log:           l.log,
-		correlationID: id,
+		log:              l.log,
+		base:             l.base,
+		level:            l.level,
+		correlationID:    l.correlationID,
+		fields:           l.fields,
+		outputs:          l.outputs,
+		traceCorrelation: l.traceCorrelation,
+		idGenerator:      l.idGenerator,
+		zapFields:        l.zapFields,
+		name:             l.name,
 	}
 }
 
+// desugared returns the zap logger of l
+func (l *logger) desugared() *zap.Logger {
+	if l.base != nil {
+		return l.base
+	}
+	return l.log.Desugar()
+}
+
+// setFields sets the correlation id and fields and makes the zap fields written with every entry
+func (l *logger) setFields(cID string, fields fields) {
+	l.correlationID = cID
+	l.fields = fields
+	l.zapFields = getFields(cID, fields)
+}
+
+// AtomicLevel returns the level of the logger; changing it affects every logger derived from the same New call
+func (l *logger) AtomicLevel() zap.AtomicLevel {
+	return l.level
+}
+
+// LevelHandler returns an http.Handler that reports the current level on GET
+// and changes it on PUT with either a JSON body ({"level":"debug"})
+// or a form body (level=debug)
+func (l *logger) LevelHandler() http.Handler {
+	return l.level
+}
+
 func getFields(cID string, fields fields) []zapcore.Field {
-	out := []zapcore.Field{}
+	out := make([]zapcore.Field, 0, len(fields)+1)
 	if cID != "" {
 		out = append(out, zap.String(CorrelationID, cID))
 	}
@@ -301,27 +638,74 @@ func getFields(cID string, fields fields) []zapcore.Field {
 		if field.key == CorrelationID {
 			continue
 		}
-		out = append(out, zap.Any(field.key, field.value))
+		out = append(out, field.zap)
 	}
 	return out
 }
 
+// Sync flushes the entries buffered by zap and WithAsync and syncs or flushes every writer.
+// Derived loggers sync the outputs they share with the logger they came from.
+func (l *logger) Sync() error {
+	err := ignoreSyncErr(l.log.Desugar().Sync())
+	if l.outputs == nil {
+		return err
+	}
+	return multierr.Append(err, l.outputs.sync())
+}
+
+// DroppedEntries returns how many entries were dropped by the WithAsync overflow policy
+func (l *logger) DroppedEntries() uint64 {
+	if l.outputs == nil || l.outputs.async == nil {
+		return 0
+	}
+	return l.outputs.async.Dropped()
+}
+
+// FlushFlightRecorder writes the entries kept by WithFlightRecorder for the correlation ids,
+// or for every correlation id when none is given, and forgets them
+func (l *logger) FlushFlightRecorder(correlationIDs ...string) error {
+	if l.outputs == nil || l.outputs.recorder == nil {
+		return nil
+	}
+	return l.outputs.recorder.flush(correlationIDs...)
+}
+
+// Close is CloseContext without a deadline
 func (l *logger) Close() error {
-	defer l.cancel()
+	return l.CloseContext(context.Background())
+}
 
-	var oerr error
-	for _, file := range l.files {
-		if err := file.Close(); err != nil {
-			oerr = fmt.Errorf("%v: %w", oerr, err)
-		}
+// CloseContext writes the buffered entries, syncs the writers, stops the background goroutines
+// and closes the log files. The files are closed without waiting any longer once ctx is done.
+// Closing more than once returns the result of the first close.
+// It does nothing on loggers derived with WithField, WithFields and WithCorrelationID
+// since they share the outputs of the logger returned by New.
+func (l *logger) CloseContext(ctx context.Context) error {
+	if !l.owner || l.outputs == nil {
+		return nil
 	}
-	return oerr
+	return l.outputs.close(ctx, l.log)
 }
 
 // Fields
 type field struct {
-	key   string
+	key string
+	// value is nil for the typed fields
 	value interface{}
+	// zap is the field as it is written
+	zap zapcore.Field
+}
+
+func newField(key string, value interface{}) field {
+	return field{key: key, value: value, zap: zap.Any(key, value)}
+}
+
+// toField keeps the zap field of the typed fields so they are written without reflection
+func toField(f Field) field {
+	if t, ok := f.(TypedField); ok {
+		return field{key: t.field.Key, zap: t.field}
+	}
+	return newField(f.Key(), f.Value())
 }
 
 type Field interface {
@@ -332,30 +716,50 @@ type Field interface {
 type fields = []field
 
 func (l *logger) WithField(key string, value interface{}) FieldLogger {
-	fields := l.fields
-	fields = append(fields, field{key, value})
+	// copy the fields so sibling loggers do not share the same backing array
+	fields := make(fields, 0, len(l.fields)+1)
+	fields = append(fields, l.fields...)
+	fields = append(fields, newField(key, value))
 
-	return &logger{
-		log:           l.log,
-		correlationID: l.correlationID,
-		fields:        fields,
-	}
+	out := l.derive()
+	out.setFields(l.correlationID, fields)
+	return out
 }
 
 func (l *logger) WithFields(in ...Field) FieldLogger {
-	fields := l.fields
+	fields := make(fields, 0, len(l.fields)+len(in))
+	fields = append(fields, l.fields...)
 	for _, f := range in {
-		fields = append(fields, field{f.Key(), f.Value()})
+		fields = append(fields, toField(f))
 	}
 
-	return &logger{
-		log:           l.log,
-		correlationID: l.correlationID,
-		fields:        fields,
+	out := l.derive()
+	out.setFields(l.correlationID, fields)
+	return out
+}
+
+// WithTypedFields is WithFields for typed fields without boxing them in the Field interface or going
+// through zap.Any. It allocates the derived logger and its fields, the same for one field as for ten.
+func (l *logger) WithTypedFields(in ...TypedField) FieldLogger {
+	fields := make(fields, 0, len(l.fields)+len(in))
+	fields = append(fields, l.fields...)
+	for _, t := range in {
+		fields = append(fields, field{key: t.field.Key, zap: t.field})
 	}
+
+	out := l.derive()
+	out.setFields(l.correlationID, fields)
+	return out
 }
 
 func argsToString(args []interface{}) string {
+	if len(args) == 1 {
+		// the usual single message is used as is
+		if s, ok := args[0].(string); ok {
+			return s
+		}
+	}
+
 	var sb strings.Builder
 	for i, arg := range args {
 
@@ -378,6 +782,34 @@ func newCore(config *Config, writer io.Writer) (newCoreFunc, error) {
 	}
 
 	return func(c zapcore.Core) zapcore.Core {
-		return zapcore.NewCore(enc, zapcore.AddSync(writer), config.zap.Level)
+		return zapcore.NewCore(enc, zapcore.AddSync(writer), config.zap.Level).
+			With(initialFields(config))
+	}, err
+}
+
+// newTeeCore writes to the writer with the encoding next to the core it wraps
+func newTeeCore(config *Config, encoding string, writer zapcore.WriteSyncer) (newCoreFunc, error) {
+	enc, err := newEncoder(encoding, config.zap.EncoderConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, zapcore.NewCore(enc, writer, config.zap.Level).With(initialFields(config)))
 	}, err
 }
+
+// initialFields returns the InitialFields of the config sorted by key the same way zap adds them
+func initialFields(config *Config) []zapcore.Field {
+	keys := make([]string, 0, len(config.zap.InitialFields))
+	for key := range config.zap.InitialFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]zapcore.Field, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, zap.Any(key, config.zap.InitialFields[key]))
+	}
+	return out
+}
@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -89,10 +92,25 @@ func TestNew(t *testing.T) {
 			want: `[{"level":"debug","msg":"debug"},
 			{"level":"info","msg":"info"}]`,
 		},
+		{
+			name: "should pass; with a rotated log file",
+			args: args{
+				logFile: "/tmp/test-go-logger.rotate.log",
+				opts: []Option{
+					WithLevel(debug),
+					WithLogFileMaxSize(1),
+					WithLogFileMaxBackups(1),
+				},
+			},
+			want: `[{"level":"debug","msg":"debug"},
+			{"level":"info","msg":"info"}]`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.args.logFile != "" {
+				// log files are appended to so start from a clean one
+				os.Remove(tt.args.logFile)
 				tt.args.opts = append(tt.args.opts, WithLogFile(tt.args.logFile))
 			}
 			if tt.args.bytes != nil {
@@ -161,3 +179,71 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestNew_badLogFile(t *testing.T) {
+	// a regular file can not be a directory of the log file
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	badPath := filepath.Join(notDir, "test.log")
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "should fail; with writers", opts: []Option{WithWriters(io.Discard)}},
+		{name: "should fail; with rotation", opts: []Option{WithLogFileMaxSize(1)}},
+		{name: "should fail; with async", opts: []Option{WithAsync()}},
+		{name: "should fail; without writers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(append(tt.opts, WithLogFile(badPath))...); err == nil {
+				t.Errorf("New() with the log file %s should fail", badPath)
+			}
+		})
+	}
+}
+
+// syncBuffer collects the output of the logger's writer goroutine
+type syncBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.Lock()
+	defer sb.Unlock()
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.Lock()
+	defer sb.Unlock()
+	return sb.buf.String()
+}
+
+// lines waits for n json log lines to be written and decodes them
+func (sb *syncBuffer) lines(t *testing.T, n int) []map[string]interface{} {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for strings.Count(sb.String(), "\n") < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d log lines: %s", n, sb.String())
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	out := []map[string]interface{}{}
+	scanner := bufio.NewScanner(strings.NewReader(sb.String()))
+	for scanner.Scan() {
+		var msg map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("unmarshal %q: %v", scanner.Text(), err)
+		}
+		out = append(out, msg)
+	}
+	return out
+}
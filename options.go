@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/joematpal/go-logger/event"
 	"go.uber.org/zap"
@@ -14,8 +15,31 @@ import (
 )
 
 type Config struct {
-	writers []io.Writer
-	zap     *zap.Config
+	writers        []io.Writer
+	encodedWriters []encodedWriter
+	rotation       Rotation
+	levelSignals   bool
+	zap            *zap.Config
+	// traceCorrelation derives the correlation id from the trace id
+	traceCorrelation bool
+	idGenerator      func() string
+	async            *asyncConfig
+	eventBuses       []event.TopicPublisher
+	flightRecorder   *flightRecorderConfig
+	sampling         *samplingConfig
+	rateLimit        *rateLimitConfig
+	// dedupWindow enables the deduplication when it is not zero
+	dedupWindow time.Duration
+	// redactRules enable the redaction when there is any
+	redactRules []RedactRule
+	// redactHashKey is the HMAC key of RedactHash
+	redactHashKey []byte
+}
+
+// encodedWriter is a writer with its own encoding
+type encodedWriter struct {
+	writer   io.Writer
+	encoding string
 }
 
 type Option interface {
@@ -39,6 +63,37 @@ func WithLevel(level string) Option {
 	})
 }
 
+// WithLevelSignals lets SIGUSR1 lower the level by one step (more verbose)
+// and SIGUSR2 raise it by one step (less verbose) while the logger is open.
+// It is not supported on windows.
+func WithLevelSignals() Option {
+	return applyOptionFunc(func(c *Config) error {
+		c.levelSignals = true
+		return nil
+	})
+}
+
+// WithTraceCorrelationID uses the trace id of the OpenTelemetry span context or W3C traceparent
+// as the correlation id when a context or request carries no correlation id
+func WithTraceCorrelationID() Option {
+	return applyOptionFunc(func(c *Config) error {
+		c.traceCorrelation = true
+		return nil
+	})
+}
+
+// WithIDGenerator sets how the correlation ids of calls that carry none, or an invalid one, are made.
+// NewXID is used by default and NewUUIDv4, NewUUIDv7 and NewULID are built in.
+func WithIDGenerator(gen func() string) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if gen == nil {
+			return errors.New("id generator is nil")
+		}
+		c.idGenerator = gen
+		return nil
+	})
+}
+
 func WithEnv(env string) Option {
 	return applyOptionFunc(func(c *Config) error {
 		if env == dev {
@@ -83,6 +138,210 @@ func WithLogFile(logFile string) Option {
 	})
 }
 
+// WithLogFileMaxSize rotates the log files once they grow over maxSize megabytes
+func WithLogFileMaxSize(maxSize int) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if maxSize < 0 {
+			return fmt.Errorf("invalid log file max size: %d", maxSize)
+		}
+		c.rotation.MaxSize = maxSize
+		return nil
+	})
+}
+
+// WithLogFileMaxAge removes rotated log files older than maxAge
+func WithLogFileMaxAge(maxAge time.Duration) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if maxAge < 0 {
+			return fmt.Errorf("invalid log file max age: %s", maxAge)
+		}
+		c.rotation.MaxAge = maxAge
+		return nil
+	})
+}
+
+// WithLogFileMaxBackups keeps at most maxBackups rotated log files
+func WithLogFileMaxBackups(maxBackups int) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if maxBackups < 0 {
+			return fmt.Errorf("invalid log file max backups: %d", maxBackups)
+		}
+		c.rotation.MaxBackups = maxBackups
+		return nil
+	})
+}
+
+// WithLogFileCompress gzips the rotated log files
+func WithLogFileCompress(compress bool) Option {
+	return applyOptionFunc(func(c *Config) error {
+		c.rotation.Compress = compress
+		return nil
+	})
+}
+
+// WithLogFileLocalTime uses the local time instead of UTC in the rotated log file names
+func WithLogFileLocalTime(localTime bool) Option {
+	return applyOptionFunc(func(c *Config) error {
+		c.rotation.LocalTime = localTime
+		return nil
+	})
+}
+
+// WithLogFileRotation sets all of the rotation settings of the log files at once
+func WithLogFileRotation(rotation Rotation) Option {
+	return applyOptionFunc(func(c *Config) error {
+		c.rotation = rotation
+		return nil
+	})
+}
+
+// WithAsync writes the entries from a background goroutine through a bounded buffer
+// so slow outputs do not block the logging goroutines.
+// Entries are written every flush interval, on Sync and on Close; Fatal and Panic entries are written right away.
+func WithAsync(opts ...AsyncOption) Option {
+	return applyOptionFunc(func(c *Config) error {
+		async := &asyncConfig{
+			bufferSize:    defaultAsyncBufferSize,
+			policy:        OverflowBlock,
+			dropLevel:     WarnLevel,
+			flushInterval: defaultAsyncFlushInterval,
+		}
+		for _, opt := range opts {
+			if err := opt.applyAsyncOption(async); err != nil {
+				return err
+			}
+		}
+		c.async = async
+		return nil
+	})
+}
+
+// WithFlightRecorder keeps the entries below the level of the logger in memory, by correlation id,
+// and writes the ones of a correlation id before its next error so failed requests come with their debug entries.
+// Entries without a correlation id are not kept.
+// The kept entries are also written by FlushFlightRecorder.
+func WithFlightRecorder(opts ...FlightRecorderOption) Option {
+	return applyOptionFunc(func(c *Config) error {
+		recorder := &flightRecorderConfig{
+			size:              defaultFlightRecorderSize,
+			maxCorrelationIDs: defaultFlightRecorderMaxCorrelationIDs,
+			triggerLevel:      ErrorLevel,
+			captureLevel:      DebugLevel,
+		}
+		for _, opt := range opts {
+			if err := opt.applyFlightRecorderOption(recorder); err != nil {
+				return err
+			}
+		}
+		c.flightRecorder = recorder
+		return nil
+	})
+}
+
+// WithSampling replaces the sampling of the production config, which ticks every second:
+// of the entries with the same level and message logged within a tick, the first initial ones
+// and every thereafter-th one after them are written. A zero initial disables the sampling.
+func WithSampling(initial, thereafter int, tick time.Duration) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if initial < 0 || thereafter < 0 {
+			return fmt.Errorf("invalid sampling: initial %d, thereafter %d", initial, thereafter)
+		}
+		c.zap.Sampling = nil
+		if initial == 0 {
+			c.sampling = nil
+			return nil
+		}
+		if tick <= 0 {
+			return fmt.Errorf("invalid sampling tick: %s", tick)
+		}
+		c.sampling = &samplingConfig{initial: initial, thereafter: thereafter, tick: tick}
+		return nil
+	})
+}
+
+// WithRateLimit limits the debug to error entries of each level and message template,
+// the format of Infof or the message of Info, to perSecond with bursts of burst entries.
+// The entries over the limit are dropped and counted in a "suppressed N similar messages" entry
+// logged every summaryInterval. A zero perSecond disables the limit.
+func WithRateLimit(perSecond float64, burst int, summaryInterval time.Duration) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if perSecond < 0 {
+			return fmt.Errorf("invalid rate limit: %v", perSecond)
+		}
+		if perSecond == 0 {
+			c.rateLimit = nil
+			return nil
+		}
+		if burst <= 0 {
+			return fmt.Errorf("invalid rate limit burst: %d", burst)
+		}
+		if summaryInterval <= 0 {
+			return fmt.Errorf("invalid rate limit summary interval: %s", summaryInterval)
+		}
+		c.rateLimit = &rateLimitConfig{perSecond: perSecond, burst: burst, summaryInterval: summaryInterval}
+		return nil
+	})
+}
+
+// WithDedup collapses the entries with the same level, logger name, message and fields logged within window.
+// The first one is written right away; its repeats are written once the window ends as one entry
+// with the repeat_count, first_seen and last_seen fields. repeat_count leaves out the first entry already
+// written and first_seen is its time. Fatal, panic and dpanic entries are not collapsed.
+func WithDedup(window time.Duration) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if window <= 0 {
+			return fmt.Errorf("invalid dedup window: %s", window)
+		}
+		c.dedupWindow = window
+		return nil
+	})
+}
+
+// WithRedaction applies the rules to every field before it is written, including the InitialFields,
+// the fields of the loggers made by WithField and WithFields and the grpc payloads of the interceptors.
+// Values implementing Redactor are logged as what their Redact method returns.
+// DefaultRedactionRules are used when no rule is given.
+func WithRedaction(rules ...RedactRule) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if len(rules) == 0 {
+			rules = DefaultRedactionRules()
+		}
+		for _, rule := range rules {
+			if rule == nil {
+				return errors.New("nil redaction rule")
+			}
+		}
+		c.redactRules = append(c.redactRules, rules...)
+		return nil
+	})
+}
+
+// WithRedactionHashKey is the secret key of the HMAC-SHA256 that RedactHash replaces values with.
+// It is required by RedactHash; the same value only hashes the same under the same key,
+// so rotating the key stops matching the values hashed before.
+func WithRedactionHashKey(key []byte) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if len(key) == 0 {
+			return errors.New("empty redaction hash key")
+		}
+		c.redactHashKey = append([]byte(nil), key...)
+		return nil
+	})
+}
+
+// WithEventBus publishes every entry on the bus with the topic of its level and logger name,
+// like "error" or "error.api.http" for a logger made Named("api").Named("http").
+// Subscribe to "error.>" for the errors of every logger or "*.api.>" for everything logged by api.
+func WithEventBus(bus event.TopicPublisher) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if bus == nil {
+			return errors.New("nil event bus")
+		}
+		c.eventBuses = append(c.eventBuses, bus)
+		return nil
+	})
+}
+
 func WithWriters(writers ...io.Writer) Option {
 	return applyOptionFunc(func(c *Config) error {
 		c.writers = append(c.writers, writers...)
@@ -90,6 +349,21 @@ func WithWriters(writers ...io.Writer) Option {
 	})
 }
 
+// WithWriterEncoding writes to the writer with the given encoding next to the other outputs of the logger.
+// The encoding can be any of the builtin ones or one added with RegisterEncoder.
+func WithWriterEncoding(writer io.Writer, encoding string) Option {
+	return applyOptionFunc(func(c *Config) error {
+		if writer == nil {
+			return errors.New("nil writer")
+		}
+		if !isEncoderRegistered(encoding) {
+			return fmt.Errorf("no encoder registered for name %q", encoding)
+		}
+		c.encodedWriters = append(c.encodedWriters, encodedWriter{writer, encoding})
+		return nil
+	})
+}
+
 func writeByNewLine(factoryError FactoryError, reader io.Reader, writers ...io.Writer) error {
 	return writeByNewLineWithContext(context.Background(), factoryError, reader, writers...)
 }
@@ -102,11 +376,17 @@ func writeByNewLineWithContext(ctx context.Context, factoryError FactoryError, r
 	e := event.New()
 	for _, writer := range writers {
 		writer := writer
+		// subscribe before publishing so no line is missed
+		itr := e.Subscribe()
 		eg.Go(func() error {
-			itr := e.Subscribe()
+			defer itr.Close()
 			for {
 				select {
-				case data := <-itr.Data():
+				case data, ok := <-itr.Data():
+					if !ok {
+						// the bus is closed and every line was written
+						return nil
+					}
 					if _, err := writer.Write(data); err != nil {
 						cancel()
 						return err
@@ -126,14 +406,19 @@ func writeByNewLineWithContext(ctx context.Context, factoryError FactoryError, r
 	scanner := bufio.NewScanner(reader)
 
 	for scanner.Scan() {
-		b := scanner.Bytes()
-		b = append(b, '\n')
+		// the scanner reuses its buffer while the subscribers may still hold the line
+		line := scanner.Bytes()
+		b := make([]byte, len(line)+1)
+		copy(b, line)
+		b[len(line)] = '\n'
 		e.Publish(b)
 	}
 
 	serr := scanner.Err()
-	cancel()
+	// closing the bus lets the writers finish the buffered lines before they return
+	e.Close()
 	err := eg.Wait()
+	cancel()
 	if err != nil {
 		return fmt.Errorf("errgroup: %w", err)
 	}
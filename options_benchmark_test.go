@@ -107,3 +107,74 @@ func Benchmark_writeByNewLine(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkWithFields_kv(b *testing.B) {
+	logr := newDiscardLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logr.WithFields(
+			KV{"user", "joe"},
+			KV{"attempt", int64(i)},
+			KV{"elapsed", time.Duration(i)},
+			KV{"retry", true},
+		).Info("request")
+	}
+}
+
+func BenchmarkWithFields_typed(b *testing.B) {
+	logr := newDiscardLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logr.WithFields(
+			String("user", "joe"),
+			Int64("attempt", int64(i)),
+			Duration("elapsed", time.Duration(i)),
+			Bool("retry", true),
+		).Info("request")
+	}
+}
+
+// the FieldLogger benchmarks log with fields made once by WithFields, KV or typed alike
+func BenchmarkFieldLogger_kv(b *testing.B) {
+	logr := newDiscardLogger().WithFields(
+		KV{"user", "joe"},
+		KV{"attempt", int64(1)},
+		KV{"elapsed", time.Second},
+		KV{"retry", true},
+	)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logr.Info("request")
+	}
+}
+
+func BenchmarkFieldLogger_typed(b *testing.B) {
+	logr := newDiscardLogger().WithFields(
+		String("user", "joe"),
+		Int64("attempt", 1),
+		Duration("elapsed", time.Second),
+		Bool("retry", true),
+	)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logr.Info("request")
+	}
+}
+
+func BenchmarkWithTypedFields(b *testing.B) {
+	logr := newDiscardLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logr.WithTypedFields(
+			String("user", "joe"),
+			Int64("attempt", int64(i)),
+			Duration("elapsed", time.Duration(i)),
+			Bool("retry", true),
+		).Info("request")
+	}
+}
@@ -87,6 +87,48 @@ func Test_writeByNewLineSync(t *testing.T) {
 	}
 }
 
+func Test_writeByNewLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		writers []io.Writer
+		wantErr bool
+	}{
+		{
+			name:    "should pass; every writer gets every line in order",
+			writers: []io.Writer{&syncBuffer{}, &syncBuffer{}, &slowWriter{latency: time.Millisecond}},
+		},
+		{
+			name:    "should fail; a writer fails",
+			writers: []io.Writer{&syncBuffer{}, errWriter{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := &bytes.Buffer{}
+			for i := 0; i < 500; i++ {
+				fmt.Fprintf(want, "%d this is a message\n", i)
+			}
+
+			err := writeByNewLine(&testDebugger{noPrint: true}, bytes.NewReader(want.Bytes()), tt.writers...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeByNewLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for _, w := range tt.writers {
+				if sb, ok := w.(*syncBuffer); ok {
+					if diff := cmp.Diff(want.String(), sb.String()); diff != "" {
+						t.Errorf("lines mismatch (-want +got):\n%s", diff)
+					}
+				}
+			}
+		})
+	}
+}
+
 type slowWriter struct {
 	sync.RWMutex
 	latency time.Duration
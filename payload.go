@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	redactedValue  = "[REDACTED]"
+	truncatedValue = "...(truncated)"
+)
+
+// PayloadDecider decides by the full grpc method (/package.Service/Method)
+// if the request and response bodies of a call are logged
+type PayloadDecider func(fullMethod string) bool
+
+// MatchMethods returns a PayloadDecider that is true for the methods matching
+// any of the path.Match patterns, like "/package.Service/*"
+func MatchMethods(patterns ...string) PayloadDecider {
+	return func(fullMethod string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, fullMethod); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// payloadRedactor turns request and response messages into loggable values
+type payloadRedactor struct {
+	// paths holds the denied field paths split by "."
+	paths   [][]string
+	maxSize int
+}
+
+func newPayloadRedactor(paths []string, maxSize int) *payloadRedactor {
+	r := &payloadRedactor{maxSize: maxSize}
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		r.paths = append(r.paths, strings.Split(p, "."))
+	}
+	return r
+}
+
+// redact returns the payload as a json value with the protobuf fields marked with
+// debug_redact and the denied paths replaced, truncated to the max size
+func (r *payloadRedactor) redact(payload interface{}) interface{} {
+	if payload == nil {
+		return nil
+	}
+
+	b, err := r.marshal(payload)
+	if err != nil {
+		return fmt.Sprintf("%+v", payload)
+	}
+
+	if len(r.paths) != 0 {
+		var tree interface{}
+		if err := json.Unmarshal(b, &tree); err == nil {
+			tree = r.redactPaths(tree, nil)
+			if rb, err := json.Marshal(tree); err == nil {
+				b = rb
+			}
+		}
+	}
+
+	if r.maxSize > 0 && len(b) > r.maxSize {
+		// backs up to the start of a rune so no character is cut in half
+		end := r.maxSize
+		for end > 0 && !utf8.RuneStart(b[end]) {
+			end--
+		}
+		return string(b[:end]) + truncatedValue
+	}
+	return rawJSON(b)
+}
+
+// rawJSON is written as is by the encoders that reflect on values
+type rawJSON []byte
+
+func (r rawJSON) MarshalJSON() ([]byte, error) {
+	return r, nil
+}
+
+func (r *payloadRedactor) marshal(payload interface{}) ([]byte, error) {
+	m, ok := payload.(proto.Message)
+	if !ok {
+		return json.Marshal(payload)
+	}
+
+	m = proto.Clone(m)
+	redactProto(m.ProtoReflect())
+	return protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+}
+
+// redactProto replaces the fields marked with the debug_redact option
+func redactProto(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDebugRedact() {
+			if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+				m.Set(fd, protoreflect.ValueOfString(redactedValue))
+			} else {
+				m.Clear(fd)
+			}
+			return true
+		}
+
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				redactProto(list.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				redactProto(mv.Message())
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			redactProto(v.Message())
+		}
+		return true
+	})
+}
+
+// redactPaths replaces the values at the denied paths.
+// A path with a single name matches the key at any depth; longer paths match from the root
+// and "*" matches any key.
+func (r *payloadRedactor) redactPaths(v interface{}, current []string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			p := append(current[:len(current):len(current)], key)
+			if r.denied(p) {
+				t[key] = redactedValue
+				continue
+			}
+			t[key] = r.redactPaths(value, p)
+		}
+	case []interface{}:
+		for i, value := range t {
+			// list items share the path of the list
+			t[i] = r.redactPaths(value, current)
+		}
+	}
+	return v
+}
+
+func (r *payloadRedactor) denied(p []string) bool {
+	for _, denied := range r.paths {
+		if len(denied) == 1 {
+			if denied[0] == p[len(p)-1] {
+				return true
+			}
+			continue
+		}
+		if len(denied) != len(p) {
+			continue
+		}
+		match := true
+		for i := range denied {
+			if denied[i] != "*" && denied[i] != p[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// newTestLoginRequest builds a message with a password field marked with debug_redact
+func newTestLoginRequest(t *testing.T, user, password string) proto.Message {
+	t.Helper()
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/login.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("LoginRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("user"),
+						JsonName: proto.String("user"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+					{
+						Name:     proto.String("password"),
+						JsonName: proto.String("password"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Options:  &descriptorpb.FieldOptions{DebugRedact: proto.Bool(true)},
+					},
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := fd.Messages().ByName("LoginRequest")
+	m := dynamicpb.NewMessage(md)
+	m.Set(md.Fields().ByName("user"), protoreflect.ValueOfString(user))
+	m.Set(md.Fields().ByName("password"), protoreflect.ValueOfString(password))
+	return m
+}
+
+func Test_payloadRedactor_redact(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		maxSize int
+		payload func(t *testing.T) interface{}
+		want    string
+	}{
+		{
+			name: "should pass; debug_redact fields",
+			payload: func(t *testing.T) interface{} {
+				return newTestLoginRequest(t, "joe", "hunter2")
+			},
+			want: `{"user":"joe","password":"[REDACTED]"}`,
+		},
+		{
+			name:  "should pass; field names at any depth",
+			paths: []string{"token"},
+			payload: func(t *testing.T) interface{} {
+				return map[string]interface{}{
+					"token": "abc",
+					"user":  map[string]interface{}{"name": "joe", "token": "def"},
+					"list":  []interface{}{map[string]interface{}{"token": "ghi"}},
+				}
+			},
+			want: `{"list":[{"token":"[REDACTED]"}],"token":"[REDACTED]","user":{"name":"joe","token":"[REDACTED]"}}`,
+		},
+		{
+			name:  "should pass; dotted paths and wildcards",
+			paths: []string{"user.name", "*.secret"},
+			payload: func(t *testing.T) interface{} {
+				return map[string]interface{}{
+					"name":  "top",
+					"user":  map[string]interface{}{"name": "joe", "secret": "x"},
+					"other": map[string]interface{}{"secret": "y"},
+				}
+			},
+			want: `{"name":"top","other":{"secret":"[REDACTED]"},"user":{"name":"[REDACTED]","secret":"[REDACTED]"}}`,
+		},
+		{
+			name:    "should pass; truncates",
+			maxSize: 10,
+			payload: func(t *testing.T) interface{} {
+				return wrapperspb.String("a very long value")
+			},
+			// wrappers are written as their bare value by protojson
+			want: `"\"a very lo...(truncated)"`,
+		},
+		{
+			name:    "should pass; truncates at a rune",
+			maxSize: 10,
+			payload: func(t *testing.T) interface{} {
+				return wrapperspb.String("abcdefghé and more")
+			},
+			want: `"\"abcdefgh...(truncated)"`,
+		},
+		{
+			name: "should pass; nil",
+			payload: func(t *testing.T) interface{} {
+				return nil
+			},
+			want: `null`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newPayloadRedactor(tt.paths, tt.maxSize)
+			b, err := json.Marshal(r.redact(tt.payload(t)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, want) {
+				t.Errorf("redact() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchMethods(t *testing.T) {
+	decider := MatchMethods("/test.Users/*", "/test.Auth/Check")
+
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{method: "/test.Users/Get", want: true},
+		{method: "/test.Auth/Check", want: true},
+		{method: "/test.Auth/Login", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if got := decider(tt.method); got != tt.want {
+				t.Errorf("MatchMethods() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package logger
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultFlightRecorderSize              = 100
+	defaultFlightRecorderMaxCorrelationIDs = 1000
+)
+
+type flightRecorderConfig struct {
+	size              int
+	window            time.Duration
+	maxCorrelationIDs int
+	triggerLevel      LogLevel
+	captureLevel      LogLevel
+}
+
+// FlightRecorderOption configures the recorder of WithFlightRecorder
+type FlightRecorderOption interface {
+	applyFlightRecorderOption(*flightRecorderConfig) error
+}
+
+type applyFlightRecorderOptionFunc func(*flightRecorderConfig) error
+
+func (f applyFlightRecorderOptionFunc) applyFlightRecorderOption(c *flightRecorderConfig) error {
+	return f(c)
+}
+
+// WithFlightRecorderSize sets how many entries are kept for each correlation id; 100 by default
+func WithFlightRecorderSize(size int) FlightRecorderOption {
+	return applyFlightRecorderOptionFunc(func(c *flightRecorderConfig) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid flight recorder size: %d", size)
+		}
+		c.size = size
+		return nil
+	})
+}
+
+// WithFlightRecorderWindow drops the kept entries once they are older than window; they are kept until
+// they are pushed out by newer entries by default
+func WithFlightRecorderWindow(window time.Duration) FlightRecorderOption {
+	return applyFlightRecorderOptionFunc(func(c *flightRecorderConfig) error {
+		if window <= 0 {
+			return fmt.Errorf("invalid flight recorder window: %s", window)
+		}
+		c.window = window
+		return nil
+	})
+}
+
+// WithFlightRecorderMaxCorrelationIDs sets how many correlation ids have entries kept;
+// the entries of the least recently logged one are dropped past it. 1000 by default
+func WithFlightRecorderMaxCorrelationIDs(max int) FlightRecorderOption {
+	return applyFlightRecorderOptionFunc(func(c *flightRecorderConfig) error {
+		if max <= 0 {
+			return fmt.Errorf("invalid flight recorder max correlation ids: %d", max)
+		}
+		c.maxCorrelationIDs = max
+		return nil
+	})
+}
+
+// WithFlightRecorderTriggerLevel sets the level of the entries that write the kept entries; ErrorLevel by default
+func WithFlightRecorderTriggerLevel(level LogLevel) FlightRecorderOption {
+	return applyFlightRecorderOptionFunc(func(c *flightRecorderConfig) error {
+		c.triggerLevel = level
+		return nil
+	})
+}
+
+// WithFlightRecorderCaptureLevel sets the lowest level of the entries kept; DebugLevel by default.
+// The entries below both it and the level of the logger are not even formatted.
+func WithFlightRecorderCaptureLevel(level LogLevel) FlightRecorderOption {
+	return applyFlightRecorderOptionFunc(func(c *flightRecorderConfig) error {
+		c.captureLevel = level
+		return nil
+	})
+}
+
+// recordedEntry is an entry below the level of the logger, kept with the core that writes it
+type recordedEntry struct {
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// recording holds the entries of a correlation id in a ring
+type recording struct {
+	id      string
+	entries []recordedEntry
+	// next is where the next entry goes once entries is full
+	next int
+	elem *list.Element
+}
+
+func (r *recording) add(e recordedEntry, size int) {
+	if len(r.entries) < size {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % size
+}
+
+// ordered returns the entries from the oldest
+func (r *recording) ordered() []recordedEntry {
+	out := make([]recordedEntry, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// flightRecorder keeps the entries below the level of the logger by correlation id
+type flightRecorder struct {
+	config flightRecorderConfig
+
+	mu         sync.Mutex
+	recordings map[string]*recording
+	// lru orders the recordings from the most recently logged
+	lru *list.List
+}
+
+func newFlightRecorder(config flightRecorderConfig) *flightRecorder {
+	return &flightRecorder{
+		config:     config,
+		recordings: map[string]*recording{},
+		lru:        list.New(),
+	}
+}
+
+func (f *flightRecorder) record(id string, e recordedEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, ok := f.recordings[id]
+	if !ok {
+		if f.lru.Len() >= f.config.maxCorrelationIDs {
+			oldest := f.lru.Remove(f.lru.Back()).(*recording)
+			delete(f.recordings, oldest.id)
+		}
+		r = &recording{id: id}
+		r.elem = f.lru.PushFront(r)
+		f.recordings[id] = r
+	} else {
+		f.lru.MoveToFront(r.elem)
+	}
+	r.add(e, f.config.size)
+}
+
+// take removes the entries of the correlation ids, all of them when none is given,
+// and returns the ones inside the window from the oldest
+func (f *flightRecorder) take(ids ...string) []recordedEntry {
+	f.mu.Lock()
+	taken := []*recording{}
+	if len(ids) == 0 {
+		for _, r := range f.recordings {
+			taken = append(taken, r)
+		}
+		f.recordings = map[string]*recording{}
+		f.lru.Init()
+	}
+	for _, id := range ids {
+		if r, ok := f.recordings[id]; ok {
+			taken = append(taken, r)
+			delete(f.recordings, id)
+			f.lru.Remove(r.elem)
+		}
+	}
+	f.mu.Unlock()
+
+	out := []recordedEntry{}
+	for _, r := range taken {
+		out = append(out, r.ordered()...)
+	}
+	if len(taken) > 1 {
+		// the entries of several correlation ids are written in the order they were logged
+		sortRecorded(out)
+	}
+	if f.config.window > 0 {
+		since := time.Now().Add(-f.config.window)
+		kept := out[:0]
+		for _, e := range out {
+			if !e.ent.Time.Before(since) {
+				kept = append(kept, e)
+			}
+		}
+		out = kept
+	}
+	return out
+}
+
+// flush writes the entries of the correlation ids, all of them when none is given
+func (f *flightRecorder) flush(ids ...string) error {
+	var err error
+	for _, e := range f.take(ids...) {
+		err = multierr.Append(err, e.core.Write(e.ent, e.fields))
+	}
+	return err
+}
+
+func sortRecorded(entries []recordedEntry) {
+	// insertion sort as each recording is already ordered
+	for i := 1; i < len(entries); i++ {
+		for j := i; j > 0 && entries[j].ent.Time.Before(entries[j-1].ent.Time); j-- {
+			entries[j], entries[j-1] = entries[j-1], entries[j]
+		}
+	}
+}
+
+// recorderCore keeps the entries that next does not write and writes them before the trigger entries
+type recorderCore struct {
+	next     zapcore.Core
+	recorder *flightRecorder
+	// correlationID is the correlation id added with With
+	correlationID string
+}
+
+func newRecorderCore(recorder *flightRecorder) newCoreFunc {
+	return func(c zapcore.Core) zapcore.Core {
+		return &recorderCore{next: c, recorder: recorder}
+	}
+}
+
+// Enabled is also true from the capture level so the entries below the level of the logger reach Check
+func (c *recorderCore) Enabled(level zapcore.Level) bool {
+	return level >= c.recorder.config.captureLevel || c.next.Enabled(level)
+}
+
+func (c *recorderCore) With(fields []zapcore.Field) zapcore.Core {
+	id := c.correlationID
+	if cID, ok := fieldsCorrelationID(fields); ok {
+		id = cID
+	}
+	return &recorderCore{
+		next:          c.next.With(fields),
+		recorder:      c.recorder,
+		correlationID: id,
+	}
+}
+
+func (c *recorderCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.next.Enabled(ent.Level) {
+		if ent.Level < c.recorder.config.captureLevel {
+			return ce
+		}
+		return ce.AddCore(ent, c)
+	}
+	if ent.Level >= c.recorder.config.triggerLevel {
+		// added before next so the kept entries are written first
+		ce = ce.AddCore(ent, c)
+	}
+	return c.next.Check(ent, ce)
+}
+
+func (c *recorderCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	id := c.correlationID
+	if cID, ok := fieldsCorrelationID(fields); ok {
+		id = cID
+	}
+
+	if id == "" {
+		// entries without correlation id are not kept, an error of one request
+		// would otherwise write the entries of every other one
+		return nil
+	}
+	if c.next.Enabled(ent.Level) {
+		return c.recorder.flush(id)
+	}
+
+	kept := make([]zapcore.Field, len(fields))
+	copy(kept, fields)
+	c.recorder.record(id, recordedEntry{core: c.next, ent: ent, fields: kept})
+	return nil
+}
+
+func (c *recorderCore) Sync() error {
+	return c.next.Sync()
+}
+
+func fieldsCorrelationID(fields []zapcore.Field) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == CorrelationID && fields[i].Type == zapcore.StringType {
+			return fields[i].String, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,196 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithFlightRecorder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []FlightRecorderOption
+		log  func(l *logger)
+		want []string
+	}{
+		{
+			name: "should pass; an error writes the debug entries of its correlation id",
+			log: func(l *logger) {
+				l.WithCorrelationID("a").Debug("a1")
+				l.WithCorrelationID("b").Debug("b1")
+				l.WithCorrelationID("a").Info("a2")
+				l.WithCorrelationID("a").Debug("a3")
+				l.WithCorrelationID("a").Error("a failed")
+			},
+			want: []string{"a2", "a1", "a3", "a failed"},
+		},
+		{
+			name: "should pass; entries without correlation id are not kept",
+			log: func(l *logger) {
+				l.Debug("1")
+				l.WithCorrelationID("a").Debug("a1")
+				l.Error("failed")
+				l.WithCorrelationID("b").Error("b failed")
+			},
+			want: []string{"failed", "b failed"},
+		},
+		{
+			name: "should pass; nothing is written without an error",
+			log: func(l *logger) {
+				l.WithCorrelationID("a").Debug("a1")
+				l.WithCorrelationID("a").Info("a2")
+			},
+			want: []string{"a2"},
+		},
+		{
+			name: "should pass; size",
+			opts: []FlightRecorderOption{WithFlightRecorderSize(2)},
+			log: func(l *logger) {
+				a := l.WithCorrelationID("a")
+				for _, msg := range []string{"1", "2", "3", "4"} {
+					a.Debug(msg)
+				}
+				a.Error("failed")
+			},
+			want: []string{"3", "4", "failed"},
+		},
+		{
+			name: "should pass; window",
+			opts: []FlightRecorderOption{WithFlightRecorderWindow(50 * time.Millisecond)},
+			log: func(l *logger) {
+				a := l.WithCorrelationID("a")
+				a.Debug("old")
+				time.Sleep(100 * time.Millisecond)
+				a.Debug("new")
+				a.Error("failed")
+			},
+			want: []string{"new", "failed"},
+		},
+		{
+			name: "should pass; max correlation ids",
+			opts: []FlightRecorderOption{WithFlightRecorderMaxCorrelationIDs(1)},
+			log: func(l *logger) {
+				l.WithCorrelationID("a").Debug("a1")
+				l.WithCorrelationID("b").Debug("b1")
+				l.WithCorrelationID("a").Error("a failed")
+				l.WithCorrelationID("b").Error("b failed")
+			},
+			want: []string{"a failed", "b1", "b failed"},
+		},
+		{
+			name: "should pass; trigger level",
+			opts: []FlightRecorderOption{WithFlightRecorderTriggerLevel(WarnLevel)},
+			log: func(l *logger) {
+				a := l.WithCorrelationID("a")
+				a.Debug("1")
+				a.Warn("slow")
+			},
+			want: []string{"1", "slow"},
+		},
+		{
+			name: "should pass; capture level",
+			opts: []FlightRecorderOption{WithFlightRecorderCaptureLevel(InfoLevel)},
+			log: func(l *logger) {
+				l.AtomicLevel().SetLevel(WarnLevel)
+				a := l.WithCorrelationID("a")
+				a.Debug("not kept")
+				a.Info("kept")
+				a.Error("failed")
+			},
+			want: []string{"kept", "failed"},
+		},
+		{
+			name: "should pass; flush a correlation id",
+			log: func(l *logger) {
+				l.WithCorrelationID("a").Debug("a1")
+				l.WithCorrelationID("b").Debug("b1")
+				if err := l.FlushFlightRecorder("b"); err != nil {
+					panic(err)
+				}
+			},
+			want: []string{"b1"},
+		},
+		{
+			name: "should pass; flush every correlation id in order",
+			log: func(l *logger) {
+				l.WithCorrelationID("a").Debug("a1")
+				l.WithCorrelationID("b").Debug("b1")
+				l.WithCorrelationID("a").Debug("a2")
+				if err := l.FlushFlightRecorder(); err != nil {
+					panic(err)
+				}
+				if err := l.FlushFlightRecorder(); err != nil {
+					panic(err)
+				}
+			},
+			want: []string{"a1", "b1", "a2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &syncBuffer{}
+			logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(sb), WithFlightRecorder(tt.opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.log(logr)
+			if err := logr.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := messages(sb.lines(t, len(tt.want)))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithFlightRecorder_options(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  FlightRecorderOption
+	}{
+		{name: "should fail; size", opt: WithFlightRecorderSize(0)},
+		{name: "should fail; window", opt: WithFlightRecorderWindow(-time.Second)},
+		{name: "should fail; max correlation ids", opt: WithFlightRecorderMaxCorrelationIDs(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(WithFlightRecorder(tt.opt)); err == nil {
+				t.Error("New() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestWithFlightRecorder_rateLimit(t *testing.T) {
+	sb := &syncBuffer{}
+	logr, err := New(
+		WithEncoding(jsonEncoder),
+		WithLogStacktrace(false),
+		WithWriters(sb),
+		WithFlightRecorder(),
+		WithRateLimit(0.001, 1, time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the debug entries are below the level so they are kept without taking tokens
+	a := logr.WithCorrelationID("a")
+	for i := 0; i < 3; i++ {
+		a.Debug("kept")
+	}
+	a.Error("failed")
+	if err := logr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"kept", "kept", "kept", "failed"}
+	if diff := cmp.Diff(want, messages(sb.lines(t, len(want)))); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,377 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redactor is implemented by the values that know how to be logged without their sensitive parts.
+// They are logged as the value returned by Redact when WithRedaction is used.
+type Redactor interface {
+	Redact() interface{}
+}
+
+// RedactAction is what a redaction rule does to what it matches
+type RedactAction int
+
+const (
+	// RedactMask replaces the value with [REDACTED]
+	RedactMask RedactAction = iota
+	// RedactHash replaces the value with the start of its HMAC-SHA256 under the key of WithRedactionHashKey
+	// so equal values can still be matched; the hashes are only stable for as long as the key is the same
+	RedactHash
+	// RedactDrop removes the field
+	RedactDrop
+)
+
+var (
+	// EmailPattern matches email addresses
+	EmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// CreditCardPattern matches 13 to 19 digit card numbers, optionally grouped with spaces or dashes
+	CreditCardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	// JWTPattern matches JSON web tokens
+	JWTPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// DefaultRedactedKeys are the field names masked by DefaultRedactionRules
+var DefaultRedactedKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"api_key",
+	"apikey",
+	"authorization",
+	"cookie",
+	"set-cookie",
+}
+
+// DefaultRedactionRules masks the DefaultRedactedKeys, emails, card numbers and JSON web tokens
+func DefaultRedactionRules() []RedactRule {
+	return []RedactRule{
+		RedactKeys(RedactMask, DefaultRedactedKeys...),
+		RedactPattern(RedactMask, EmailPattern),
+		RedactPattern(RedactMask, CreditCardPattern),
+		RedactPattern(RedactMask, JWTPattern),
+	}
+}
+
+// RedactRule is a rule of WithRedaction
+type RedactRule interface {
+	applyRedactRule(*redactor)
+}
+
+type applyRedactRuleFunc func(*redactor)
+
+func (f applyRedactRuleFunc) applyRedactRule(r *redactor) {
+	f(r)
+}
+
+// RedactKeys applies the action to the values of the fields with the names, compared without case,
+// at any depth of maps, structs and grpc payloads
+func RedactKeys(action RedactAction, keys ...string) RedactRule {
+	return applyRedactRuleFunc(func(r *redactor) {
+		for _, key := range keys {
+			r.keys[strings.ToLower(key)] = action
+		}
+		r.hashes = r.hashes || action == RedactHash
+	})
+}
+
+// RedactPattern applies the action to the parts of string values matching the pattern;
+// RedactDrop removes the whole field
+func RedactPattern(action RedactAction, pattern *regexp.Regexp) RedactRule {
+	return applyRedactRuleFunc(func(r *redactor) {
+		r.patterns = append(r.patterns, patternRule{pattern: pattern, action: action})
+		r.hashes = r.hashes || action == RedactHash
+	})
+}
+
+// RedactType applies the action to the values with the type of v
+func RedactType(action RedactAction, v interface{}) RedactRule {
+	return applyRedactRuleFunc(func(r *redactor) {
+		r.types[reflect.TypeOf(v)] = action
+		r.hashes = r.hashes || action == RedactHash
+	})
+}
+
+type patternRule struct {
+	pattern *regexp.Regexp
+	action  RedactAction
+}
+
+// redactor applies the rules of WithRedaction to fields
+type redactor struct {
+	keys     map[string]RedactAction
+	patterns []patternRule
+	types    map[reflect.Type]RedactAction
+	// hashes is set when a rule uses RedactHash, which needs hashKey
+	hashes  bool
+	hashKey []byte
+}
+
+func newRedactor(rules []RedactRule, hashKey []byte) (*redactor, error) {
+	r := &redactor{
+		keys:    map[string]RedactAction{},
+		types:   map[reflect.Type]RedactAction{},
+		hashKey: hashKey,
+	}
+	for _, rule := range rules {
+		rule.applyRedactRule(r)
+	}
+	if r.hashes && len(hashKey) == 0 {
+		// an unkeyed hash of a card number or an email is reversed by trying them all
+		return nil, errors.New("RedactHash needs WithRedactionHashKey")
+	}
+	return r, nil
+}
+
+func hashValue(key []byte, s string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// act returns what the action makes of the value; false when it is dropped
+func (r *redactor) act(action RedactAction, value string) (string, bool) {
+	switch action {
+	case RedactHash:
+		return hashValue(r.hashKey, value), true
+	case RedactDrop:
+		return "", false
+	default:
+		return redactedValue, true
+	}
+}
+
+// redactString applies the patterns and reports if the string changed; false when it is dropped
+func (r *redactor) redactString(s string) (string, bool, bool) {
+	changed := false
+	for _, p := range r.patterns {
+		if !p.pattern.MatchString(s) {
+			continue
+		}
+		if p.action == RedactDrop {
+			return "", true, false
+		}
+		s = p.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			out, _ := r.act(p.action, match)
+			return out
+		})
+		changed = true
+	}
+	return s, changed, true
+}
+
+// fields returns the fields with the rules applied; in is not modified
+func (r *redactor) fields(in []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(in))
+	for _, f := range in {
+		if f, ok := r.field(f); ok {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// field applies the rules to a field; false when it is dropped
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if action, ok := r.keys[strings.ToLower(f.Key)]; ok {
+		value, keep := r.act(action, fieldString(f))
+		return zap.String(f.Key, value), keep
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		s, changed, keep := r.redactString(f.String)
+		if changed {
+			return zap.String(f.Key, s), keep
+		}
+		return f, keep
+	case zapcore.ErrorType, zapcore.StringerType, zapcore.ReflectType,
+		zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+	default:
+		return f, true
+	}
+
+	if v, ok := f.Interface.(Redactor); ok {
+		redacted := v.Redact()
+		if _, again := redacted.(Redactor); again {
+			// masked rather than redacted over and over
+			return zap.String(f.Key, redactedValue), true
+		}
+		return r.field(zap.Any(f.Key, redacted))
+	}
+	if action, ok := r.types[reflect.TypeOf(f.Interface)]; ok {
+		value, keep := r.act(action, fieldString(f))
+		return zap.String(f.Key, value), keep
+	}
+
+	switch f.Type {
+	case zapcore.ErrorType, zapcore.StringerType:
+		s, changed, keep := r.redactString(fieldString(f))
+		if changed {
+			return zap.String(f.Key, s), keep
+		}
+		return f, keep
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		v, changed, keep := r.redactValue(enc.Fields[f.Key])
+		if changed {
+			return zap.Any(f.Key, v), keep
+		}
+		return f, keep
+	default:
+		b, err := json.Marshal(f.Interface)
+		if err != nil {
+			return f, true
+		}
+		var tree interface{}
+		if err := json.Unmarshal(b, &tree); err != nil {
+			return f, true
+		}
+		v, changed, keep := r.redactValue(tree)
+		if !changed {
+			return f, keep
+		}
+		if b, err = json.Marshal(v); err != nil {
+			return f, keep
+		}
+		return zap.Any(f.Key, rawJSON(b)), keep
+	}
+}
+
+// redactValue applies the rules inside maps, lists and strings; false when the value is dropped
+func (r *redactor) redactValue(v interface{}) (interface{}, bool, bool) {
+	switch t := v.(type) {
+	case string:
+		return r.redactString(t)
+	case map[string]interface{}:
+		changed := false
+		for key, value := range t {
+			if action, ok := r.keys[strings.ToLower(key)]; ok {
+				s, keep := r.act(action, fmt.Sprint(value))
+				if keep {
+					t[key] = s
+				} else {
+					delete(t, key)
+				}
+				changed = true
+				continue
+			}
+			out, c, keep := r.redactValue(value)
+			switch {
+			case !keep:
+				delete(t, key)
+			case c:
+				t[key] = out
+			}
+			changed = changed || c || !keep
+		}
+		return t, changed, true
+	case []interface{}:
+		changed := false
+		out := t[:0]
+		for _, value := range t {
+			v, c, keep := r.redactValue(value)
+			if keep {
+				out = append(out, v)
+			}
+			changed = changed || c || !keep
+		}
+		return out, changed, true
+	default:
+		return v, false, true
+	}
+}
+
+// fieldString returns the value of the field as it would print
+func fieldString(f zapcore.Field) string {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return err.Error()
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return fmt.Sprint(enc.Fields[f.Key])
+}
+
+// initialFields applies the rules to the InitialFields so they are redacted like the others
+func (r *redactor) initialFields(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return fields
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range r.fields(mapFields(fields)) {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+func mapFields(m map[string]interface{}) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(m))
+	for key, value := range m {
+		out = append(out, zap.Any(key, value))
+	}
+	return out
+}
+
+// redactCore applies the rules to the fields before the cores under it get them
+type redactCore struct {
+	next     zapcore.Core
+	redactor *redactor
+}
+
+func newRedactCore(r *redactor) newCoreFunc {
+	return func(c zapcore.Core) zapcore.Core {
+		return &redactCore{next: c, redactor: r}
+	}
+}
+
+func (c *redactCore) Enabled(level zapcore.Level) bool {
+	return c.next.Enabled(level)
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{
+		next:     c.next.With(c.redactor.fields(fields)),
+		redactor: c.redactor,
+	}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	writeChecked(c.next, ent, c.redactor.fields(fields))
+	return nil
+}
+
+func (c *redactCore) Sync() error {
+	return c.next.Sync()
+}
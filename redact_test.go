@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testAccount struct {
+	ID     int    `json:"id"`
+	Secret string `json:"secret"`
+}
+
+func (a testAccount) Redact() interface{} {
+	return map[string]interface{}{"id": a.ID}
+}
+
+type testCard struct {
+	Number string `json:"number"`
+}
+
+func TestWithRedaction(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		log    func(l *logger)
+		want   map[string]interface{}
+		absent []string
+	}{
+		{
+			name: "should pass; default rules",
+			opts: []Option{WithRedaction()},
+			log: func(l *logger) {
+				l.WithFields(
+					KV{"Password", "hunter2"},
+					KV{"email", "joe@example.com"},
+					KV{"note", "card 4111 1111 1111 1111 declined"},
+					KV{"auth", "Bearer eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln"},
+					KV{"error", errors.New("no user joe@example.com")},
+					KV{"count", 3},
+				).Info("login")
+			},
+			want: map[string]interface{}{
+				"Password": "[REDACTED]",
+				"email":    "[REDACTED]",
+				"note":     "card [REDACTED] declined",
+				"auth":     "Bearer [REDACTED]",
+				"error":    "no user [REDACTED]",
+				"count":    float64(3),
+			},
+		},
+		{
+			name: "should pass; nested values",
+			opts: []Option{WithRedaction()},
+			log: func(l *logger) {
+				l.WithField("user", map[string]interface{}{
+					"name":   "joe",
+					"token":  "abc",
+					"emails": []string{"joe@example.com"},
+				}).Info("login")
+			},
+			want: map[string]interface{}{
+				"user": map[string]interface{}{
+					"name":   "joe",
+					"token":  "[REDACTED]",
+					"emails": []interface{}{"[REDACTED]"},
+				},
+			},
+		},
+		{
+			name: "should pass; hash and drop",
+			opts: []Option{WithRedaction(
+				RedactKeys(RedactHash, "user_id"),
+				RedactKeys(RedactDrop, "session"),
+				RedactPattern(RedactDrop, EmailPattern),
+			), WithRedactionHashKey([]byte("secret"))},
+			log: func(l *logger) {
+				l.WithFields(
+					KV{"user_id", 42},
+					KV{"session", "s1"},
+					KV{"email", "joe@example.com"},
+					KV{"emails", []string{"joe@example.com", "none"}},
+				).Info("login")
+			},
+			want: map[string]interface{}{
+				"user_id": hashValue([]byte("secret"), "42"),
+				"emails":  []interface{}{"none"},
+			},
+			absent: []string{"session", "email"},
+		},
+		{
+			name: "should pass; types",
+			opts: []Option{WithRedaction(RedactType(RedactMask, testCard{}))},
+			log: func(l *logger) {
+				l.WithFields(
+					KV{"account", testAccount{ID: 1, Secret: "s"}},
+					KV{"card", testCard{Number: "4111"}},
+				).Info("charge")
+			},
+			want: map[string]interface{}{
+				"account": map[string]interface{}{"id": float64(1)},
+				"card":    "[REDACTED]",
+			},
+		},
+		{
+			name: "should pass; initial fields",
+			opts: []Option{
+				WithRedaction(),
+				WithInitialFields(map[string]interface{}{"api_key": "k", "app": "test"}),
+			},
+			log: func(l *logger) {
+				l.Info("started")
+			},
+			want: map[string]interface{}{
+				"api_key": "[REDACTED]",
+				"app":     "test",
+			},
+		},
+		{
+			name: "should pass; without redaction",
+			log: func(l *logger) {
+				l.WithField("password", "hunter2").Info("login")
+			},
+			want: map[string]interface{}{"password": "hunter2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &syncBuffer{}
+			opts := append([]Option{WithEncoding(jsonEncoder), WithWriters(sb)}, tt.opts...)
+			logr, err := New(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.log(logr)
+			if err := logr.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			line := sb.lines(t, 1)[0]
+			got := map[string]interface{}{}
+			for key := range tt.want {
+				got[key] = line[key]
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("fields mismatch (-want +got):\n%s", diff)
+			}
+			for _, key := range tt.absent {
+				if v, ok := line[key]; ok {
+					t.Errorf("%s = %v, want it dropped", key, v)
+				}
+			}
+		})
+	}
+}
+
+func TestWithRedaction_grpcPayloads(t *testing.T) {
+	sb := &syncBuffer{}
+	logr, err := New(WithEncoding(jsonEncoder), WithWriters(sb), WithRedaction(RedactKeys(RedactMask, "token")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_CorrelationID, "cor_id"))
+	req := map[string]interface{}{"user": "joe", "token": "secret"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Users/Get"}
+	if _, err := LoggingUnaryServerInterceptor(logr, WithPayloads(true))(ctx, req, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := logr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"user": "joe", "token": "[REDACTED]"}
+	if diff := cmp.Diff(want, sb.lines(t, 2)[0]["request"]); diff != "" {
+		t.Errorf("request mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithRedaction_nilRule(t *testing.T) {
+	if _, err := New(WithRedaction(nil)); err == nil {
+		t.Error("New() with a nil redaction rule should fail")
+	}
+}
+
+func TestWithRedaction_hashKey(t *testing.T) {
+	if _, err := New(WithRedaction(RedactKeys(RedactHash, "user_id"))); err == nil {
+		t.Error("New() with RedactHash and no hash key should fail")
+	}
+	if _, err := New(WithRedactionHashKey(nil)); err == nil {
+		t.Error("New() with an empty hash key should fail")
+	}
+	if hashValue([]byte("a"), "42") == hashValue([]byte("b"), "42") {
+		t.Error("hashValue() should differ between keys")
+	}
+}
@@ -0,0 +1,399 @@
+// The rotation, backup naming, milling and compression of rotatingFile are adapted from
+// https://github.com/natefinch/lumberjack, under the following license:
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2014 Nate Finch
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+	megabyte         = 1024 * 1024
+)
+
+// Rotation configures how log files added by WithLogFile are rotated.
+// The zero value disables rotation.
+type Rotation struct {
+	// MaxSize is the maximum size in megabytes of the log file before it gets rotated
+	MaxSize int
+	// MaxAge is the maximum time to retain old log files based on the timestamp in their name
+	MaxAge time.Duration
+	// MaxBackups is the maximum number of old log files to retain
+	MaxBackups int
+	// Compress determines if the rotated log files should be gzipped
+	Compress bool
+	// LocalTime determines if the time used in the backup file names is the local time; UTC is the default
+	LocalTime bool
+}
+
+func (r Rotation) enabled() bool {
+	return r.MaxSize > 0 || r.MaxAge > 0 || r.MaxBackups > 0
+}
+
+// rotatingFile is an io.WriteCloser that appends to filename and rotates it
+// once it grows over the configured size.
+type rotatingFile struct {
+	sync.Mutex
+	filename string
+	rotation Rotation
+	file     *os.File
+	size     int64
+	now      func() time.Time
+	// closed refuses the writes after Close instead of opening the file again
+	closed bool
+
+	millOnce sync.Once
+	millCh   chan struct{}
+	millDone chan struct{}
+}
+
+// newRotatingFile opens the file right away so a path that can not be written fails New
+// rather than the first write, whose error zap would only report to its error output
+func newRotatingFile(filename string, rotation Rotation) (*rotatingFile, error) {
+	r := &rotatingFile{
+		filename: filename,
+		rotation: rotation,
+		now:      time.Now,
+	}
+	if err := r.openExistingOrNew(0); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	max := int64(r.rotation.MaxSize) * megabyte
+	if max > 0 && int64(len(p)) > max {
+		return 0, fmt.Errorf("write length %d exceeds maximum file size %d", len(p), max)
+	}
+
+	if r.file == nil {
+		if err := r.openExistingOrNew(len(p)); err != nil {
+			return 0, err
+		}
+	}
+
+	if max > 0 && r.size+int64(len(p)) > max {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Sync() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+func (r *rotatingFile) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.millCh != nil {
+		close(r.millCh)
+		<-r.millDone
+		r.millCh = nil
+	}
+
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *rotatingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return fmt.Errorf("close: %v", err)
+		}
+		r.file = nil
+	}
+
+	if _, err := os.Stat(r.filename); err == nil {
+		if err := os.Rename(r.filename, r.backupName()); err != nil {
+			return fmt.Errorf("rename: %v", err)
+		}
+	}
+
+	if err := r.openNew(); err != nil {
+		return err
+	}
+	r.mill()
+	return nil
+}
+
+func (r *rotatingFile) openExistingOrNew(writeLen int) error {
+	r.mill()
+
+	info, err := os.Stat(r.filename)
+	if os.IsNotExist(err) {
+		return r.openNew()
+	}
+	if err != nil {
+		return fmt.Errorf("stat: %v", err)
+	}
+
+	if max := int64(r.rotation.MaxSize) * megabyte; max > 0 && info.Size()+int64(writeLen) > max {
+		return r.rotate()
+	}
+
+	f, err := os.OpenFile(r.filename, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		// starting a new file would truncate the entries of the previous run
+		return fmt.Errorf("open: %v", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) openNew() error {
+	if err := os.MkdirAll(filepath.Dir(r.filename), 0755); err != nil {
+		return fmt.Errorf("mkdir: %v", err)
+	}
+
+	f, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("open: %v", err)
+	}
+	r.file = f
+	r.size = 0
+	return nil
+}
+
+func (r *rotatingFile) currentTime() time.Time {
+	if r.rotation.LocalTime {
+		return r.now()
+	}
+	return r.now().UTC()
+}
+
+func (r *rotatingFile) backupName() string {
+	dir := filepath.Dir(r.filename)
+	prefix, ext := r.prefixAndExt()
+	return filepath.Join(dir, fmt.Sprintf("%s%s%s", prefix, r.currentTime().Format(backupTimeFormat), ext))
+}
+
+func (r *rotatingFile) prefixAndExt() (string, string) {
+	base := filepath.Base(r.filename)
+	ext := filepath.Ext(base)
+	return base[:len(base)-len(ext)] + "-", ext
+}
+
+// mill signals the background goroutine to compress and remove old backups
+func (r *rotatingFile) mill() {
+	if !r.rotation.enabled() && !r.rotation.Compress {
+		return
+	}
+	r.millOnce.Do(func() {
+		r.millCh = make(chan struct{}, 1)
+		r.millDone = make(chan struct{})
+		go r.millRun(r.millCh, r.millDone)
+	})
+	if r.millCh == nil {
+		return
+	}
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *rotatingFile) millRun(ch <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for range ch {
+		// errors are ignored; there is nowhere to report them to but the log itself
+		_ = r.millRunOnce()
+	}
+}
+
+type backupFile struct {
+	os.FileInfo
+	timestamp time.Time
+}
+
+func (r *rotatingFile) millRunOnce() error {
+	files, err := r.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	var compress, remove []backupFile
+
+	if r.rotation.MaxBackups > 0 && r.rotation.MaxBackups < len(files) {
+		preserved := map[string]bool{}
+		var kept []backupFile
+		for _, f := range files {
+			// a file and its compressed copy only count once
+			name := strings.TrimSuffix(f.Name(), compressSuffix)
+			preserved[name] = true
+			if len(preserved) > r.rotation.MaxBackups {
+				remove = append(remove, f)
+			} else {
+				kept = append(kept, f)
+			}
+		}
+		files = kept
+	}
+
+	if r.rotation.MaxAge > 0 {
+		cutoff := r.currentTime().Add(-r.rotation.MaxAge)
+		var kept []backupFile
+		for _, f := range files {
+			if f.timestamp.Before(cutoff) {
+				remove = append(remove, f)
+			} else {
+				kept = append(kept, f)
+			}
+		}
+		files = kept
+	}
+
+	if r.rotation.Compress {
+		for _, f := range files {
+			if !strings.HasSuffix(f.Name(), compressSuffix) {
+				compress = append(compress, f)
+			}
+		}
+	}
+
+	dir := filepath.Dir(r.filename)
+	var oerr error
+	for _, f := range remove {
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			oerr = fmt.Errorf("%v: %w", oerr, err)
+		}
+	}
+	for _, f := range compress {
+		name := filepath.Join(dir, f.Name())
+		if err := compressLogFile(name, name+compressSuffix); err != nil {
+			oerr = fmt.Errorf("%v: %w", oerr, err)
+		}
+	}
+	return oerr
+}
+
+// oldLogFiles returns the backups of the log file sorted newest first
+func (r *rotatingFile) oldLogFiles() ([]backupFile, error) {
+	entries, err := os.ReadDir(filepath.Dir(r.filename))
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %v", err)
+	}
+
+	prefix, ext := r.prefixAndExt()
+	out := []backupFile{}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if t, err := r.timeFromName(e.Name(), prefix, ext); err == nil {
+			out = append(out, backupFile{info, t})
+			continue
+		}
+		if t, err := r.timeFromName(e.Name(), prefix, ext+compressSuffix); err == nil {
+			out = append(out, backupFile{info, t})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].timestamp.After(out[j].timestamp)
+	})
+	return out, nil
+}
+
+func (r *rotatingFile) timeFromName(filename, prefix, ext string) (time.Time, error) {
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, ext) {
+		return time.Time{}, fmt.Errorf("mismatched prefix or extension")
+	}
+	ts := filename[len(prefix) : len(filename)-len(ext)]
+	if r.rotation.LocalTime {
+		return time.ParseInLocation(backupTimeFormat, ts, time.Local)
+	}
+	return time.Parse(backupTimeFormat, ts)
+}
+
+func compressLogFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open: %v", err)
+	}
+	defer f.Close()
+
+	gzf, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open compressed: %v", err)
+	}
+
+	gz := gzip.NewWriter(gzf)
+	if _, err := io.Copy(gz, f); err != nil {
+		gzf.Close()
+		os.Remove(dst)
+		return fmt.Errorf("compress: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		gzf.Close()
+		os.Remove(dst)
+		return fmt.Errorf("compress: %v", err)
+	}
+	if err := gzf.Close(); err != nil {
+		return fmt.Errorf("close compressed: %v", err)
+	}
+	return os.Remove(src)
+}
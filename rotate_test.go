@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_rotatingFile_Write(t *testing.T) {
+	line := append(bytes.Repeat([]byte("a"), megabyte/2-1), '\n')
+
+	tests := []struct {
+		name        string
+		rotation    Rotation
+		existing    []string
+		writes      int
+		wantBackups int
+		wantSuffix  string
+	}{
+		{
+			name:        "should pass; appends without rotation",
+			writes:      3,
+			wantBackups: 0,
+		},
+		{
+			name:        "should pass; rotates over max size",
+			rotation:    Rotation{MaxSize: 1},
+			writes:      5,
+			wantBackups: 2,
+			wantSuffix:  ".log",
+		},
+		{
+			name:        "should pass; keeps max backups",
+			rotation:    Rotation{MaxSize: 1, MaxBackups: 1},
+			writes:      7,
+			wantBackups: 1,
+			wantSuffix:  ".log",
+		},
+		{
+			name:        "should pass; compresses backups",
+			rotation:    Rotation{MaxSize: 1, Compress: true},
+			writes:      3,
+			wantBackups: 1,
+			wantSuffix:  ".log.gz",
+		},
+		{
+			name:     "should pass; removes backups older than max age",
+			rotation: Rotation{MaxSize: 1, MaxAge: time.Hour},
+			existing: []string{
+				"test-" + time.Now().UTC().Add(-time.Hour*2).Format(backupTimeFormat) + ".log",
+			},
+			writes:      3,
+			wantBackups: 1,
+			wantSuffix:  ".log",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "test.log")
+
+			for _, name := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("old\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r, err := newRotatingFile(filename, tt.rotation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// make sure every backup gets a unique name
+			now := time.Now()
+			r.now = func() time.Time {
+				now = now.Add(time.Second)
+				return now
+			}
+
+			for i := 0; i < tt.writes; i++ {
+				if _, err := r.Write(line); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backups := []string{}
+			for _, e := range entries {
+				if e.Name() != "test.log" {
+					backups = append(backups, e.Name())
+				}
+			}
+			sort.Strings(backups)
+
+			if len(backups) != tt.wantBackups {
+				t.Fatalf("backups = %v, want %d", backups, tt.wantBackups)
+			}
+			for _, b := range backups {
+				if !strings.HasSuffix(b, tt.wantSuffix) {
+					t.Errorf("backup %s does not end with %s", b, tt.wantSuffix)
+				}
+			}
+
+			info, err := os.Stat(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.rotation.MaxSize > 0 && info.Size() > int64(tt.rotation.MaxSize)*megabyte {
+				t.Errorf("log file size %d is over the max size", info.Size())
+			}
+		})
+	}
+}
+
+func Test_rotatingFile_appends(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+
+	for _, msg := range []string{"first run\n", "second run\n"} {
+		r, err := newRotatingFile(filename, Rotation{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "first run\nsecond run\n"; string(got) != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func Test_rotatingFile_closed(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+	r, err := newRotatingFile(filename, Rotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("before close\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Write([]byte("after close\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() after Close error = %v, want %v", err, os.ErrClosed)
+	}
+	if r.file != nil {
+		t.Error("the file was opened again after Close")
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "before close\n"; string(got) != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func Test_rotatingFile_appendError(t *testing.T) {
+	// a directory exists but can not be opened for appending
+	filename := filepath.Join(t.TempDir(), "test.log")
+	if err := os.Mkdir(filename, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newRotatingFile(filename, Rotation{}); err == nil {
+		t.Error("newRotatingFile() should fail when the existing file can not be appended to")
+	}
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxRateLimitKeys is how many level and template pairs are limited at once;
+// the entries of new pairs go through unlimited past it until the idle ones are forgotten
+const maxRateLimitKeys = 10000
+
+type samplingConfig struct {
+	initial    int
+	thereafter int
+	tick       time.Duration
+}
+
+// newSamplerCore drops the repeated entries following the sampling config
+func newSamplerCore(config samplingConfig) newCoreFunc {
+	return func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(c, config.tick, config.initial, config.thereafter)
+	}
+}
+
+type rateLimitConfig struct {
+	perSecond       float64
+	burst           int
+	summaryInterval time.Duration
+}
+
+type rateLimitKey struct {
+	level    LogLevel
+	template string
+}
+
+// bucket is the token bucket of a level and template
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int
+}
+
+// rateLimiter limits the entries by level and message template with token buckets
+// and logs how many were suppressed every summary interval
+type rateLimiter struct {
+	config rateLimitConfig
+	log    *zap.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[rateLimitKey]*bucket
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newRateLimiter(config rateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		config:  config,
+		now:     time.Now,
+		buckets: map[rateLimitKey]*bucket{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// start logs the summaries to log until close
+func (r *rateLimiter) start(log *zap.Logger) {
+	r.log = log
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.config.summaryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.summarize()
+			case <-r.stop:
+				r.summarize()
+				return
+			}
+		}
+	}()
+}
+
+// allow takes a token from the bucket of the level and template
+func (r *rateLimiter) allow(level LogLevel, template string) bool {
+	now := r.now()
+	key := rateLimitKey{level: level, template: template}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[key]
+	if !ok {
+		if len(r.buckets) >= maxRateLimitKeys {
+			return true
+		}
+		b = &bucket{tokens: float64(r.config.burst), last: now}
+		r.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * r.config.perSecond
+	if max := float64(r.config.burst); b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		b.suppressed++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// summarize logs the suppressed counts and forgets the buckets that filled up again
+func (r *rateLimiter) summarize() {
+	type summary struct {
+		key        rateLimitKey
+		suppressed int
+	}
+
+	now := r.now()
+	summaries := []summary{}
+
+	r.mu.Lock()
+	for key, b := range r.buckets {
+		if b.suppressed > 0 {
+			summaries = append(summaries, summary{key: key, suppressed: b.suppressed})
+			b.suppressed = 0
+			continue
+		}
+		if float64(r.config.burst) <= b.tokens+now.Sub(b.last).Seconds()*r.config.perSecond {
+			delete(r.buckets, key)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, s := range summaries {
+		msg := fmt.Sprintf("suppressed %d similar messages", s.suppressed)
+		if ce := r.log.Check(s.key.level, msg); ce != nil {
+			ce.Write(zap.Int("suppressed", s.suppressed), zap.String("template", s.key.template))
+		}
+	}
+}
+
+// close logs the last summary and stops the summaries
+func (r *rateLimiter) close() {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+// allow reports whether the WithRateLimit limit lets an entry of the level and template through.
+// Entries that would not be written do not take a token.
+func (l *logger) allow(level LogLevel, template string) bool {
+	if l.outputs == nil || l.outputs.limiter == nil {
+		return true
+	}
+	if !l.level.Enabled(level) {
+		return true
+	}
+	return l.outputs.limiter.allow(level, template)
+}
@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithSampling(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     Option
+		entries int
+		want    []float64
+		wantErr bool
+	}{
+		{
+			name:    "should pass; initial and thereafter",
+			opt:     WithSampling(2, 3, time.Minute),
+			entries: 10,
+			want:    []float64{0, 1, 4, 7},
+		},
+		{
+			name:    "should pass; zero initial disables the sampling",
+			opt:     WithSampling(0, 0, 0),
+			entries: 150,
+		},
+		{
+			name:    "should fail; negative thereafter",
+			opt:     WithSampling(1, -1, time.Second),
+			wantErr: true,
+		},
+		{
+			name:    "should fail; zero tick",
+			opt:     WithSampling(1, 1, 0),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &syncBuffer{}
+			logr, err := New(WithEncoding(jsonEncoder), WithWriters(sb), tt.opt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for i := 0; i < tt.entries; i++ {
+				logr.WithField("n", i).Info("same")
+			}
+			if err := logr.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := tt.want
+			if want == nil {
+				for i := 0; i < tt.entries; i++ {
+					want = append(want, float64(i))
+				}
+			}
+			got := []float64{}
+			for _, line := range sb.lines(t, len(want)) {
+				got = append(got, line["n"].(float64))
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	sb := &syncBuffer{}
+	logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(sb), WithRateLimit(0.001, 2, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		logr.Infof("hot %d", i)
+	}
+	logr.Info("other")
+	for i := 0; i < 3; i++ {
+		logr.Errorf("hot %d", i)
+	}
+	// the last summary is written on close
+	if err := logr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := sb.lines(t, 7)
+	if diff := cmp.Diff([]string{"hot 0", "hot 1", "other", "hot 0", "hot 1"}, messages(lines[:5])); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+
+	got := map[string]interface{}{}
+	for _, line := range lines[5:] {
+		got[line["level"].(string)+" "+line["msg"].(string)] = line["template"]
+	}
+	want := map[string]interface{}{
+		"info suppressed 3 similar messages":  "hot %d",
+		"error suppressed 1 similar messages": "hot %d",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("summaries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_rateLimiter_allow(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newRateLimiter(rateLimitConfig{perSecond: 1, burst: 2, summaryInterval: time.Hour})
+	r.now = func() time.Time { return now }
+
+	got := []bool{}
+	step := func(d time.Duration) {
+		now = now.Add(d)
+		got = append(got, r.allow(InfoLevel, "msg"))
+	}
+	step(0)
+	step(0)
+	step(0)
+	step(500 * time.Millisecond)
+	step(500 * time.Millisecond)
+	step(time.Hour)
+	step(0)
+	step(0)
+
+	want := []bool{true, true, false, false, true, true, true, false}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("allow mismatch (-want +got):\n%s", diff)
+	}
+	if got := r.buckets[rateLimitKey{InfoLevel, "msg"}].suppressed; got != 3 {
+		t.Errorf("suppressed = %v, want 3", got)
+	}
+}
+
+func TestWithRateLimit_options(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     Option
+		wantErr bool
+	}{
+		{name: "should pass; zero disables the limit", opt: WithRateLimit(0, 0, 0)},
+		{name: "should fail; negative rate", opt: WithRateLimit(-1, 1, time.Second), wantErr: true},
+		{name: "should fail; zero burst", opt: WithRateLimit(1, 0, time.Second), wantErr: true},
+		{name: "should fail; zero summary interval", opt: WithRateLimit(1, 1, 0), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logr, err := New(tt.opt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				logr.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,449 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler is a slog.Handler that writes through a CorrelationLogger
+type slogHandler struct {
+	logger CorrelationLogger
+	goas   []groupOrAttrs
+}
+
+// groupOrAttrs holds either a group name or the attrs added by WithAttrs
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewSlogHandler returns a slog.Handler backed by the logger.
+// A top level correlation_id attr sets the correlation id of the logger
+// and groups are written as nested objects.
+// Entries keep the time and caller of the records.
+func NewSlogHandler(logger CorrelationLogger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if l, ok := h.logger.(interface{ AtomicLevel() zap.AtomicLevel }); ok {
+		return l.AtomicLevel().Enabled(slogToLevel(level))
+	}
+	return true
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+func (h *slogHandler) withGroupOrAttrs(goa groupOrAttrs) *slogHandler {
+	goas := make([]groupOrAttrs, 0, len(h.goas)+1)
+	goas = append(goas, h.goas...)
+	goas = append(goas, goa)
+	return &slogHandler{
+		logger: h.logger,
+		goas:   goas,
+	}
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	logr := h.logger
+	top := []Field{}
+	// current is the map of the innermost open group; nil at the top level
+	var current Map
+	var groups []string
+
+	add := func(a slog.Attr) {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			return
+		}
+		if current == nil {
+			if a.Key == CorrelationID {
+				logr = logr.WithCorrelationID(a.Value.String())
+				return
+			}
+			if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+				for _, ga := range a.Value.Group() {
+					top = append(top, KV{ga.Key, slogValue(ga.Value)})
+				}
+				return
+			}
+			top = append(top, KV{a.Key, slogValue(a.Value)})
+			return
+		}
+		if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+			for _, ga := range a.Value.Group() {
+				current[ga.Key] = slogValue(ga.Value)
+			}
+			return
+		}
+		current[a.Key] = slogValue(a.Value)
+	}
+
+	// groups only hold the attrs added after they were opened
+	maps := []Map{}
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			groups = append(groups, goa.group)
+			current = Map{}
+			maps = append(maps, current)
+			continue
+		}
+		for _, a := range goa.attrs {
+			add(a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		add(a)
+		return true
+	})
+
+	// nest the groups from the inside out and drop the empty ones like slog does
+	var nested interface{}
+	for i := len(maps) - 1; i >= 0; i-- {
+		if nested != nil {
+			maps[i][groups[i+1]] = nested
+		}
+		if len(maps[i]) == 0 {
+			nested = nil
+			continue
+		}
+		nested = maps[i]
+	}
+	if nested != nil {
+		top = append(top, KV{groups[0], nested})
+	}
+
+	var out Logger = logr
+	if len(top) > 0 {
+		out = logr.WithFields(top...)
+	}
+
+	level := slogToLevel(r.Level)
+	if l, ok := out.(*logger); ok {
+		l.withContext(ctx).writeSlogRecord(level, r)
+		return nil
+	}
+
+	cl, ok := out.(ContextLogger)
+	if !ok {
+		cl = logr
+	}
+
+	switch {
+	case level <= DebugLevel:
+		cl.DebugContext(ctx, r.Message)
+	case level == InfoLevel:
+		cl.InfoContext(ctx, r.Message)
+	case level == WarnLevel:
+		cl.WarnContext(ctx, r.Message)
+	default:
+		cl.ErrorContext(ctx, r.Message)
+	}
+	return nil
+}
+
+// writeSlogRecord writes r through the core of l so the entry keeps the time and caller of the record
+func (l *logger) writeSlogRecord(level LogLevel, r slog.Record) {
+	if !l.allow(level, r.Message) {
+		return
+	}
+	ent := zapcore.Entry{
+		LoggerName: l.name,
+		Time:       r.Time,
+		Level:      level,
+		Message:    r.Message,
+	}
+	if ent.Time.IsZero() {
+		ent.Time = time.Now()
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, frame.PC != 0)
+		ent.Caller.Function = frame.Function
+	}
+	if ce := l.desugared().Core().Check(ent, nil); ce != nil {
+		ce.Write(l.zapFields...)
+	}
+}
+
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindGroup:
+		m := Map{}
+		for _, a := range v.Group() {
+			m[a.Key] = slogValue(a.Value.Resolve())
+		}
+		return m
+	case slog.KindLogValuer:
+		return slogValue(v.Resolve())
+	default:
+		return v.Any()
+	}
+}
+
+func slogToLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+// slogLogger makes a slog.Logger satisfy CorrelationLogger
+type slogLogger struct {
+	// log never has the correlation id, which is added when logging so it is written once
+	log           *slog.Logger
+	correlationID string
+}
+
+// NewSlogLogger returns a CorrelationLogger that writes to the slog.Logger.
+// DPanic logs at the error level, Panic panics and Fatal exits after logging.
+func NewSlogLogger(log *slog.Logger) CorrelationLogger {
+	return &slogLogger{log: log}
+}
+
+// logContext logs msg with the keys and values of Infow and the like;
+// slog writes the values without a string key before them under BadKey
+func (s *slogLogger) logContext(ctx context.Context, level slog.Level, msg string, keysAndValues ...interface{}) {
+	cID := s.correlationID
+	if cID == "" {
+		cID = correlationIDFromContext(ctx)
+	}
+	s.log.Log(ctx, level, msg, slogArgs(cID, keysAndValues)...)
+}
+
+// slogArgs puts the correlation id first and turns the Field and zap Field values into attrs, as slog only knows its own
+func slogArgs(cID string, keysAndValues []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(keysAndValues)+1)
+	if cID != "" {
+		out = append(out, slog.String(CorrelationID, cID))
+	}
+	for _, kv := range keysAndValues {
+		switch f := kv.(type) {
+		case zapcore.Field:
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			kv = slog.Any(f.Key, enc.Fields[f.Key])
+		case Field:
+			kv = slog.Any(f.Key(), f.Value())
+		}
+		out = append(out, kv)
+	}
+	return out
+}
+
+func (s *slogLogger) Debug(args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelDebug, argsToString(args))
+}
+
+func (s *slogLogger) Debugf(format string, args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelDebug, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Info(args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelInfo, argsToString(args))
+}
+
+func (s *slogLogger) Infof(format string, args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Warn(args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelWarn, argsToString(args))
+}
+
+func (s *slogLogger) Warnf(format string, args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Error(args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, argsToString(args))
+}
+
+func (s *slogLogger) Errorf(format string, args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) DPanic(args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, argsToString(args))
+}
+
+func (s *slogLogger) DPanicf(format string, args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Panic(args ...interface{}) {
+	msg := argsToString(args)
+	s.logContext(context.Background(), slog.LevelError, msg)
+	panic(msg)
+}
+
+func (s *slogLogger) Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	s.logContext(context.Background(), slog.LevelError, msg)
+	panic(msg)
+}
+
+func (s *slogLogger) Fatal(args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, argsToString(args))
+	os.Exit(1)
+}
+
+func (s *slogLogger) Fatalf(format string, args ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (s *slogLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	s.logContext(context.Background(), slog.LevelDebug, msg, keysAndValues...)
+}
+
+func (s *slogLogger) Infow(msg string, keysAndValues ...interface{}) {
+	s.logContext(context.Background(), slog.LevelInfo, msg, keysAndValues...)
+}
+
+func (s *slogLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	s.logContext(context.Background(), slog.LevelWarn, msg, keysAndValues...)
+}
+
+func (s *slogLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, msg, keysAndValues...)
+}
+
+func (s *slogLogger) DPanicw(msg string, keysAndValues ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, msg, keysAndValues...)
+}
+
+func (s *slogLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, msg, keysAndValues...)
+	panic(msg)
+}
+
+func (s *slogLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	s.logContext(context.Background(), slog.LevelError, msg, keysAndValues...)
+	os.Exit(1)
+}
+
+func (s *slogLogger) DebugContext(ctx context.Context, args ...interface{}) {
+	s.logContext(ctx, slog.LevelDebug, argsToString(args))
+}
+
+func (s *slogLogger) DebugfContext(ctx context.Context, format string, args ...interface{}) {
+	s.logContext(ctx, slog.LevelDebug, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) InfoContext(ctx context.Context, args ...interface{}) {
+	s.logContext(ctx, slog.LevelInfo, argsToString(args))
+}
+
+func (s *slogLogger) InfofContext(ctx context.Context, format string, args ...interface{}) {
+	s.logContext(ctx, slog.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) WarnContext(ctx context.Context, args ...interface{}) {
+	s.logContext(ctx, slog.LevelWarn, argsToString(args))
+}
+
+func (s *slogLogger) WarnfContext(ctx context.Context, format string, args ...interface{}) {
+	s.logContext(ctx, slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) ErrorContext(ctx context.Context, args ...interface{}) {
+	s.logContext(ctx, slog.LevelError, argsToString(args))
+}
+
+func (s *slogLogger) ErrorfContext(ctx context.Context, format string, args ...interface{}) {
+	s.logContext(ctx, slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) DPanicContext(ctx context.Context, args ...interface{}) {
+	s.logContext(ctx, slog.LevelError, argsToString(args))
+}
+
+func (s *slogLogger) DPanicfContext(ctx context.Context, format string, args ...interface{}) {
+	s.logContext(ctx, slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) PanicContext(ctx context.Context, args ...interface{}) {
+	msg := argsToString(args)
+	s.logContext(ctx, slog.LevelError, msg)
+	panic(msg)
+}
+
+func (s *slogLogger) PanicfContext(ctx context.Context, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	s.logContext(ctx, slog.LevelError, msg)
+	panic(msg)
+}
+
+func (s *slogLogger) FatalContext(ctx context.Context, args ...interface{}) {
+	s.logContext(ctx, slog.LevelError, argsToString(args))
+	os.Exit(1)
+}
+
+func (s *slogLogger) FatalfContext(ctx context.Context, format string, args ...interface{}) {
+	s.logContext(ctx, slog.LevelError, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (s *slogLogger) WithField(key string, value interface{}) FieldLogger {
+	return &slogLogger{
+		log:           s.log.With(key, value),
+		correlationID: s.correlationID,
+	}
+}
+
+func (s *slogLogger) WithFields(in ...Field) FieldLogger {
+	args := make([]interface{}, 0, len(in)*2)
+	for _, f := range in {
+		args = append(args, f.Key(), f.Value())
+	}
+	return &slogLogger{
+		log:           s.log.With(args...),
+		correlationID: s.correlationID,
+	}
+}
+
+func (s *slogLogger) WithTypedFields(in ...TypedField) FieldLogger {
+	args := make([]interface{}, 0, len(in))
+	for _, t := range in {
+		args = append(args, slog.Any(t.Key(), t.Value()))
+	}
+	return &slogLogger{
+		log:           s.log.With(args...),
+		correlationID: s.correlationID,
+	}
+}
+
+func (s *slogLogger) WithCorrelationID(id string) CorrelationLogger {
+	return &slogLogger{
+		log:           s.log,
+		correlationID: id,
+	}
+}
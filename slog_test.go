@@ -0,0 +1,198 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+)
+
+func TestNewSlogHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want map[string]interface{}
+	}{
+		{
+			name: "should pass; with attrs",
+			log: func(l *slog.Logger) {
+				l.Info("info", "key", "value", "count", 2)
+			},
+			want: map[string]interface{}{"level": "info", "msg": "info", "key": "value", "count": float64(2)},
+		},
+		{
+			name: "should pass; with correlation id",
+			log: func(l *slog.Logger) {
+				l.With(CorrelationID, "slog_cor_id").Warn("warn")
+			},
+			want: map[string]interface{}{"level": "warn", "msg": "warn", "correlation_id": "slog_cor_id"},
+		},
+		{
+			name: "should pass; with groups",
+			log: func(l *slog.Logger) {
+				l.With("top", 1).WithGroup("req").With("method", "GET").WithGroup("empty").Error("error", slog.Group("user", "id", "abc"))
+			},
+			want: map[string]interface{}{
+				"level": "error",
+				"msg":   "error",
+				"top":   float64(1),
+				"req": map[string]interface{}{
+					"method": "GET",
+					"empty": map[string]interface{}{
+						"user": map[string]interface{}{"id": "abc"},
+					},
+				},
+			},
+		},
+		{
+			name: "should pass; debug is filtered by the level",
+			log: func(l *slog.Logger) {
+				l.Debug("debug")
+				l.Info("info")
+			},
+			want: map[string]interface{}{"level": "info", "msg": "info"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &syncBuffer{}
+			logr, err := New(
+				WithLevel(info),
+				WithEncoding(jsonEncoder),
+				WithLogStacktrace(false),
+				WithWriters(buf),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.log(slog.New(NewSlogHandler(logr)))
+
+			got := buf.lines(t, 1)[0]
+			delete(got, "ts")
+			delete(got, "caller")
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("diff: %v", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestNewSlogHandler_record(t *testing.T) {
+	buf := &syncBuffer{}
+	logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := slog.NewRecord(ts, slog.LevelInfo, "info", pcs[0])
+	if err := NewSlogHandler(logr.Named("slog")).Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if err := logr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.lines(t, 1)[0]
+	want := map[string]interface{}{
+		"level":  "info",
+		"msg":    "info",
+		"logger": "slog",
+		"ts":     "2022-01-02T03:04:05.000Z",
+	}
+	caller, _ := got["caller"].(string)
+	delete(got, "caller")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("line mismatch (-want +got):\n%s", diff)
+	}
+	if !strings.Contains(caller, "/slog_test.go:") {
+		t.Errorf("caller = %q, want the line of the record in slog_test.go", caller)
+	}
+}
+
+func TestNewSlogLogger(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l CorrelationLogger)
+		want map[string]interface{}
+	}{
+		{
+			name: "should pass; with correlation id",
+			log: func(l CorrelationLogger) {
+				l.WithCorrelationID("cor_id").Infof("hello %s", "world")
+			},
+			want: map[string]interface{}{"level": "INFO", "msg": "hello world", "correlation_id": "cor_id"},
+		},
+		{
+			name: "should pass; with fields",
+			log: func(l CorrelationLogger) {
+				l.WithFields(KV{"key", "value"}).Error("error", "happened")
+			},
+			want: map[string]interface{}{"level": "ERROR", "msg": "error happened", "key": "value"},
+		},
+		{
+			name: "should pass; correlation id from the context",
+			log: func(l CorrelationLogger) {
+				ctx := IntoContext(context.Background(), newNopLogger().WithCorrelationID("ctx_cor_id"))
+				l.WarnContext(ctx, "warn")
+			},
+			want: map[string]interface{}{"level": "WARN", "msg": "warn", "correlation_id": "ctx_cor_id"},
+		},
+		{
+			name: "should pass; with keys and values",
+			log: func(l CorrelationLogger) {
+				l.WithCorrelationID("cor_id").Infow("hello", "key", "value", String("typed", "v"), zap.Int("zap", 1), 42)
+			},
+			want: map[string]interface{}{"level": "INFO", "msg": "hello", "correlation_id": "cor_id", "key": "value", "typed": "v", "zap": float64(1), BadKey: float64(42)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			sl := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+
+			tt.log(NewSlogLogger(sl))
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("diff: %v", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestNewSlogLogger_chainedCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	l.WithCorrelationID("a").WithField("key", "value").(CorrelationLogger).WithCorrelationID("b").Info("info")
+
+	if got := strings.Count(buf.String(), `"correlation_id"`); got != 1 {
+		t.Errorf("correlation_id written %d times, want once: %s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), `"correlation_id":"b"`) || !strings.Contains(buf.String(), `"key":"value"`) {
+		t.Errorf("line = %s, want correlation id b and the field", buf.String())
+	}
+}
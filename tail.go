@@ -0,0 +1,342 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/joematpal/go-logger/event"
+	"go.uber.org/zap/zapcore"
+)
+
+// tailBufferSize is how many entries a slow Subscribe iterator buffers before entries are dropped for it
+const tailBufferSize = 256
+
+// tailEncoderConfig encodes the entries published to the Subscribe iterators
+var tailEncoderConfig = zapcore.EncoderConfig{
+	TimeKey:        "ts",
+	LevelKey:       "level",
+	NameKey:        "logger",
+	MessageKey:     "msg",
+	LineEnding:     zapcore.DefaultLineEnding,
+	EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+	EncodeLevel:    zapcore.LowercaseLevelEncoder,
+	EncodeDuration: zapcore.StringDurationEncoder,
+	EncodeName:     zapcore.FullNameEncoder,
+}
+
+// Entry is a log entry streamed by Subscribe
+type Entry struct {
+	Time          time.Time              `json:"ts"`
+	Level         LogLevel               `json:"level"`
+	LoggerName    string                 `json:"logger,omitempty"`
+	Message       string                 `json:"msg"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+	Fields        map[string]interface{} `json:"fields,omitempty"`
+}
+
+// EntryFilter selects the entries streamed by Subscribe
+type EntryFilter func(e Entry) bool
+
+// FilterLevel selects the entries at level or above
+func FilterLevel(level LogLevel) EntryFilter {
+	return func(e Entry) bool {
+		return e.Level >= level
+	}
+}
+
+// FilterLogger selects the entries of the named logger and of the loggers named under it
+func FilterLogger(name string) EntryFilter {
+	return func(e Entry) bool {
+		return e.LoggerName == name || strings.HasPrefix(e.LoggerName, name+".")
+	}
+}
+
+// FilterCorrelationID selects the entries of the correlation id
+func FilterCorrelationID(id string) EntryFilter {
+	return func(e Entry) bool {
+		return e.CorrelationID == id
+	}
+}
+
+// FilterField selects the entries with the field key whose value prints as value
+func FilterField(key, value string) EntryFilter {
+	return func(e Entry) bool {
+		v, ok := e.Fields[key]
+		return ok && fmt.Sprint(v) == value
+	}
+}
+
+// FilterAll selects the entries selected by every filter
+func FilterAll(filters ...EntryFilter) EntryFilter {
+	return func(e Entry) bool {
+		for _, filter := range filters {
+			if filter != nil && !filter(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// tail publishes the entries of a logger to its Subscribe iterators
+type tail struct {
+	bus *event.Event
+	// subscribers keeps the entries from being encoded when nobody listens
+	subscribers int32
+}
+
+func newTail() *tail {
+	return &tail{
+		bus: event.New(event.WithBufferSize(tailBufferSize), event.WithPolicy(event.Drop)),
+	}
+}
+
+// tailCore encodes the entries for the tail while it has subscribers
+type tailCore struct {
+	zapcore.LevelEnabler
+	enc  zapcore.Encoder
+	tail *tail
+}
+
+func newTailCore(config *Config, t *tail) newCoreFunc {
+	return func(c zapcore.Core) zapcore.Core {
+		tc := &tailCore{
+			LevelEnabler: config.zap.Level,
+			enc:          zapcore.NewJSONEncoder(tailEncoderConfig),
+			tail:         t,
+		}
+		return zapcore.NewTee(c, tc.With(initialFields(config)))
+	}
+}
+
+func (c *tailCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &tailCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          c.enc.Clone(),
+		tail:         c.tail,
+	}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *tailCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if atomic.LoadInt32(&c.tail.subscribers) > 0 && c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *tailCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	b := make([]byte, buf.Len())
+	copy(b, buf.Bytes())
+	buf.Free()
+
+	c.tail.bus.PublishTopic(entryTopic(ent), b)
+	return nil
+}
+
+func (c *tailCore) Sync() error {
+	return nil
+}
+
+// EntryIterator streams the entries of Subscribe
+type EntryIterator struct {
+	itr    event.FactoryEvent
+	tail   *tail
+	filter EntryFilter
+	closed int32
+
+	entry Entry
+	err   error
+}
+
+// Next waits for the next entry selected by the filter. It returns false once ctx is done,
+// the iterator or the logger is closed; Err tells why.
+func (it *EntryIterator) Next(ctx context.Context) bool {
+	for {
+		select {
+		case data, ok := <-it.itr.Data():
+			if !ok {
+				it.err = it.itr.Error()
+				return false
+			}
+			entry, err := decodeEntry(data)
+			if err != nil {
+				it.err = err
+				return false
+			}
+			if it.filter != nil && !it.filter(entry) {
+				continue
+			}
+			it.entry = entry
+			return true
+		case <-ctx.Done():
+			it.err = ctx.Err()
+			return false
+		}
+	}
+}
+
+// Entry returns the entry found by Next
+func (it *EntryIterator) Entry() Entry {
+	return it.entry
+}
+
+// Err returns why Next returned false; nil when the iterator or the logger was closed
+func (it *EntryIterator) Err() error {
+	return it.err
+}
+
+// Dropped returns how many entries were dropped because the iterator did not keep up
+func (it *EntryIterator) Dropped() uint64 {
+	return it.itr.Dropped()
+}
+
+// Close stops the iterator
+func (it *EntryIterator) Close() error {
+	if it.tail != nil && atomic.CompareAndSwapInt32(&it.closed, 0, 1) {
+		atomic.AddInt32(&it.tail.subscribers, -1)
+	}
+	return it.itr.Close()
+}
+
+func decodeEntry(data []byte) (Entry, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Entry{}, fmt.Errorf("decode entry: %v", err)
+	}
+
+	e := Entry{}
+	if ts, ok := fields[tailEncoderConfig.TimeKey].(string); ok {
+		e.Time, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	if level, ok := fields[tailEncoderConfig.LevelKey].(string); ok {
+		_ = e.Level.UnmarshalText([]byte(level))
+	}
+	e.LoggerName, _ = fields[tailEncoderConfig.NameKey].(string)
+	e.Message, _ = fields[tailEncoderConfig.MessageKey].(string)
+	e.CorrelationID, _ = fields[CorrelationID].(string)
+
+	for _, key := range []string{
+		tailEncoderConfig.TimeKey,
+		tailEncoderConfig.LevelKey,
+		tailEncoderConfig.NameKey,
+		tailEncoderConfig.MessageKey,
+		CorrelationID,
+	} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		e.Fields = fields
+	}
+	return e, nil
+}
+
+// Subscribe streams the entries written by the logger, and the loggers sharing its outputs,
+// that are selected by filter; a nil filter selects every entry.
+// Entries are dropped for an iterator that does not keep up instead of slowing the logger down.
+func (l *logger) Subscribe(filter EntryFilter) *EntryIterator {
+	if l.outputs == nil || l.outputs.tail == nil {
+		// nothing is ever written so the iterator is already closed
+		bus := event.New()
+		bus.Close()
+		return &EntryIterator{itr: bus.Subscribe(), filter: filter}
+	}
+
+	t := l.outputs.tail
+	atomic.AddInt32(&t.subscribers, 1)
+	return &EntryIterator{
+		itr:    t.bus.Subscribe(),
+		tail:   t,
+		filter: filter,
+	}
+}
+
+// TailHandler returns an http.Handler that streams the entries of the logger as they are written.
+// The response is Server-Sent Events when the request accepts text/event-stream or format=sse is set,
+// and newline delimited JSON otherwise. The entries are selected with the query parameters
+// level (the lowest level), logger, correlation_id and field=key:value, which can be repeated.
+func (l *logger) TailHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := filterFromQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		sse := r.URL.Query().Get("format") == "sse" ||
+			(r.URL.Query().Get("format") == "" && strings.Contains(r.Header.Get("Accept"), "text/event-stream"))
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+
+		// subscribed before the headers are sent so the client gets every entry logged after them
+		it := l.Subscribe(filter)
+		defer it.Close()
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for it.Next(r.Context()) {
+			b, err := json.Marshal(it.Entry())
+			if err != nil {
+				continue
+			}
+			if sse {
+				_, err = fmt.Fprintf(w, "data: %s\n\n", b)
+			} else {
+				_, err = fmt.Fprintf(w, "%s\n", b)
+			}
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	})
+}
+
+func filterFromQuery(r *http.Request) (EntryFilter, error) {
+	q := r.URL.Query()
+	filters := []EntryFilter{}
+
+	if level := q.Get("level"); level != "" {
+		var lvl LogLevel
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid level: %s", level)
+		}
+		filters = append(filters, FilterLevel(lvl))
+	}
+	if name := q.Get("logger"); name != "" {
+		filters = append(filters, FilterLogger(name))
+	}
+	if id := q.Get(CorrelationID); id != "" {
+		filters = append(filters, FilterCorrelationID(id))
+	}
+	for _, field := range q["field"] {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field filter: %s, want key:value", field)
+		}
+		filters = append(filters, FilterField(key, value))
+	}
+	return FilterAll(filters...), nil
+}
@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogger_Subscribe(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter EntryFilter
+		want   []string
+	}{
+		{
+			name: "should pass; every entry",
+			want: []string{"started", "request", "failed", "api failed"},
+		},
+		{
+			name:   "should pass; level",
+			filter: FilterLevel(ErrorLevel),
+			want:   []string{"failed", "api failed"},
+		},
+		{
+			name:   "should pass; logger",
+			filter: FilterLogger("api"),
+			want:   []string{"api failed"},
+		},
+		{
+			name:   "should pass; field and correlation id",
+			filter: FilterAll(FilterField("user", "42"), FilterCorrelationID("abc")),
+			want:   []string{"request"},
+		},
+		{
+			name:   "should pass; no match",
+			filter: FilterField("user", "7"),
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(&syncBuffer{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			it := logr.Subscribe(tt.filter)
+			defer it.Close()
+
+			logr.Info("started")
+			logr.WithCorrelationID("abc").WithField("user", 42).Info("request")
+			logr.Error("failed")
+			logr.Named("api").Error("api failed")
+			logr.Close()
+
+			got := []string{}
+			for it.Next(context.Background()) {
+				got = append(got, it.Entry().Message)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLogger_Subscribe_entry(t *testing.T) {
+	logr, err := New(WithEncoding(jsonEncoder), WithWriters(&syncBuffer{}), WithInitialFields(map[string]interface{}{"app": "test"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := logr.Subscribe(nil)
+	defer it.Close()
+
+	logr.Named("api").WithCorrelationID("abc").WithField("user", "42").Warn("slow")
+	logr.Close()
+
+	if !it.Next(context.Background()) {
+		t.Fatalf("Next() = false, err %v", it.Err())
+	}
+	got := it.Entry()
+	if got.Time.IsZero() {
+		t.Error("Entry().Time is zero")
+	}
+	got.Time = time.Time{}
+
+	want := Entry{
+		Level:         WarnLevel,
+		LoggerName:    "api",
+		Message:       "slow",
+		CorrelationID: "abc",
+		Fields:        map[string]interface{}{"app": "test", "user": "42"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Entry() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLogger_Subscribe_context(t *testing.T) {
+	logr, err := New(WithWriters(&syncBuffer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logr.Close()
+
+	it := logr.Subscribe(nil)
+	defer it.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if it.Next(ctx) {
+		t.Fatal("Next() = true without entries")
+	}
+	if it.Err() != context.DeadlineExceeded {
+		t.Errorf("Err() = %v, want %v", it.Err(), context.DeadlineExceeded)
+	}
+}
+
+func TestLogger_TailHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		accept     string
+		wantStatus int
+		wantType   string
+		want       []string
+	}{
+		{
+			name:       "should pass; ndjson",
+			query:      "?level=warn",
+			wantStatus: http.StatusOK,
+			wantType:   "application/x-ndjson",
+			want:       []string{"failed", "api failed"},
+		},
+		{
+			name:       "should pass; sse",
+			query:      "?logger=api",
+			accept:     "text/event-stream",
+			wantStatus: http.StatusOK,
+			wantType:   "text/event-stream",
+			want:       []string{"api failed"},
+		},
+		{
+			name:       "should pass; field",
+			query:      "?format=sse&field=user:42",
+			wantStatus: http.StatusOK,
+			wantType:   "text/event-stream",
+			want:       []string{"request"},
+		},
+		{
+			name:       "should fail; invalid level",
+			query:      "?level=loud",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "should fail; invalid field",
+			query:      "?field=user",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logr, err := New(WithEncoding(jsonEncoder), WithLogStacktrace(false), WithWriters(&syncBuffer{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer logr.Close()
+
+			srv := httptest.NewServer(logr.TailHandler())
+			defer srv.Close()
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %v, want %v", got, tt.wantType)
+			}
+
+			// the headers are flushed once the handler subscribed
+			logr.Info("started")
+			logr.WithField("user", 42).Info("request")
+			logr.Error("failed")
+			logr.Named("api").Error("api failed")
+
+			scanner := bufio.NewScanner(resp.Body)
+			got := []string{}
+			for len(got) < len(tt.want) && scanner.Scan() {
+				line := scanner.Text()
+				if tt.wantType == "text/event-stream" {
+					if line == "" {
+						continue
+					}
+					if !strings.HasPrefix(line, "data: ") {
+						t.Fatalf("line %q is not an event", line)
+					}
+					line = strings.TrimPrefix(line, "data: ")
+				}
+				e := Entry{}
+				if err := json.Unmarshal([]byte(line), &e); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, e.Message)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
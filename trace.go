@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// Key_Traceparent is the W3C trace context header and grpc metadata key
+var Key_Traceparent = "traceparent"
+
+const (
+	traceIDKey    = "trace_id"
+	spanIDKey     = "span_id"
+	traceFlagsKey = "trace_flags"
+)
+
+// parseTraceparent parses a W3C traceparent value, version-traceid-spanid-flags.
+// Versions after 00 may carry more fields which are ignored as the spec asks.
+func parseTraceparent(s string) (trace.SpanContext, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return trace.SpanContext{}, false
+	}
+
+	version := s[0:2]
+	if !isLowerHex(version) || version == "ff" {
+		return trace.SpanContext{}, false
+	}
+	if len(s) > 55 && (version == "00" || s[55] != '-') {
+		return trace.SpanContext{}, false
+	}
+
+	traceID, err := trace.TraceIDFromHex(s[3:35])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(s[36:52])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	if !isLowerHex(s[53:55]) {
+		return trace.SpanContext{}, false
+	}
+	flags, _ := hex.DecodeString(s[53:55])
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.TraceFlags(flags[0]),
+		Remote:     true,
+	})
+	return sc, sc.IsValid()
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// contextWithTraceparent returns ctx carrying the span context of the traceparent value
+// as a remote span unless ctx already has a valid span context
+func contextWithTraceparent(ctx context.Context, traceparent string) context.Context {
+	if traceparent == "" || trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	sc, ok := parseTraceparent(traceparent)
+	if !ok {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// spanContextFromContext returns the OpenTelemetry span context of ctx
+// and falls back to the traceparent of the incoming grpc metadata
+func spanContextFromContext(ctx context.Context) trace.SpanContext {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return sc
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return trace.SpanContext{}
+	}
+	for _, val := range md.Get(Key_Traceparent) {
+		if sc, ok := parseTraceparent(val); ok {
+			return sc
+		}
+	}
+	return trace.SpanContext{}
+}
+
+// traceFields returns the trace_id, span_id and trace_flags fields of the span context
+func traceFields(sc trace.SpanContext) []Field {
+	if !sc.IsValid() {
+		return nil
+	}
+	return []Field{
+		KV{traceIDKey, sc.TraceID().String()},
+		KV{spanIDKey, sc.SpanID().String()},
+		KV{traceFlagsKey, sc.TraceFlags().String()},
+	}
+}
+
+// traceCorrelationID returns the trace id of ctx when the logger was made WithTraceCorrelationID
+func traceCorrelationID(ctx context.Context, logr CorrelationLogger) (string, bool) {
+	l, ok := logr.(*logger)
+	if !ok || !l.traceCorrelation {
+		return "", false
+	}
+	sc := spanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", false
+	}
+	return sc.TraceID().String(), true
+}
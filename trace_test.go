@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID      = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testSpanID + "-01"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantOK    bool
+		wantFlags string
+	}{
+		{
+			name:      "should pass; sampled",
+			value:     testTraceparent,
+			wantOK:    true,
+			wantFlags: "01",
+		},
+		{
+			name:      "should pass; future version with more fields",
+			value:     "01-" + testTraceID + "-" + testSpanID + "-00-extra",
+			wantOK:    true,
+			wantFlags: "00",
+		},
+		{
+			name:  "should fail; version 00 with more fields",
+			value: testTraceparent + "-extra",
+		},
+		{
+			name:  "should fail; invalid version",
+			value: "ff-" + testTraceID + "-" + testSpanID + "-01",
+		},
+		{
+			name:  "should fail; zero trace id",
+			value: "00-00000000000000000000000000000000-" + testSpanID + "-01",
+		},
+		{
+			name:  "should fail; upper case",
+			value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID + "-01",
+		},
+		{
+			name:  "should fail; too short",
+			value: "00-" + testTraceID + "-01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := parseTraceparent(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parseTraceparent() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got := sc.TraceID().String(); got != testTraceID {
+				t.Errorf("trace id = %v, want %v", got, testTraceID)
+			}
+			if got := sc.SpanID().String(); got != testSpanID {
+				t.Errorf("span id = %v, want %v", got, testSpanID)
+			}
+			if got := sc.TraceFlags().String(); got != tt.wantFlags {
+				t.Errorf("trace flags = %v, want %v", got, tt.wantFlags)
+			}
+		})
+	}
+}
+
+func TestLogger_withContext_trace(t *testing.T) {
+	sc, _ := parseTraceparent(testTraceparent)
+
+	tests := []struct {
+		name            string
+		logr            *logger
+		ctx             context.Context
+		wantCorrelation string
+		wantTraceFields bool
+	}{
+		{
+			name:            "should pass; span context",
+			logr:            newNopLogger(),
+			ctx:             trace.ContextWithSpanContext(context.Background(), sc),
+			wantTraceFields: true,
+		},
+		{
+			name:            "should pass; traceparent metadata",
+			logr:            newNopLogger(),
+			ctx:             metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key_Traceparent, testTraceparent)),
+			wantTraceFields: true,
+		},
+		{
+			name:            "should pass; correlation id from the trace",
+			logr:            &logger{log: newNopLogger().log, traceCorrelation: true},
+			ctx:             trace.ContextWithSpanContext(context.Background(), sc),
+			wantCorrelation: testTraceID,
+			wantTraceFields: true,
+		},
+		{
+			name: "should pass; correlation id wins over the trace",
+			logr: &logger{log: newNopLogger().log, traceCorrelation: true},
+			ctx: metadata.NewIncomingContext(
+				trace.ContextWithSpanContext(context.Background(), sc),
+				metadata.Pairs(Key_CorrelationID, "md_cor_id"),
+			),
+			wantCorrelation: "md_cor_id",
+			wantTraceFields: true,
+		},
+		{
+			name: "should pass; without a trace",
+			logr: newNopLogger(),
+			ctx:  context.Background(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.logr.withContext(tt.ctx)
+			if got.correlationID != tt.wantCorrelation {
+				t.Errorf("correlationID = %v, want %v", got.correlationID, tt.wantCorrelation)
+			}
+
+			values := map[string]interface{}{}
+			for _, f := range got.fields {
+				values[f.key] = f.value
+			}
+			if !tt.wantTraceFields {
+				if len(values) != 0 {
+					t.Errorf("fields = %v, want none", values)
+				}
+				return
+			}
+			wants := map[string]interface{}{
+				traceIDKey:    testTraceID,
+				spanIDKey:     testSpanID,
+				traceFlagsKey: "01",
+			}
+			for key, want := range wants {
+				if values[key] != want {
+					t.Errorf("%s = %v, want %v", key, values[key], want)
+				}
+			}
+		})
+	}
+}